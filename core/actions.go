@@ -0,0 +1,49 @@
+package core
+
+// Action is what a Trigger asks of a Relay: one of the Action constants, possibly with an argument
+// such as a duration, e.g. "On 30m", or a Setting name with its value
+type Action string
+
+// Actions a Relay understands in Trigger.Action. Parse also accepts "on", "ON", "off" and "OFF".
+const (
+	ActionOn       Action = "On" // optionally followed by a duration, e.g. "On 30m", or ActionIndefinitely
+	ActionOff      Action = "Off"
+	ActionToggle   Action = "Toggle"   // "On" if the load is off, "Off" if it is on
+	ActionPulse    Action = "Pulse"    // followed by a duration, e.g. "Pulse 200ms": a timed "On" that can't be indefinite
+	ActionAnnounce Action = "Announce" // see Announce
+	ActionValidate Action = "Validate" // prefixes any other action for a dry run, e.g. "Validate On"
+
+	ActionIndefinitely Action = "indefinitely" // follows ActionOn to ask for an indefinite run
+
+	ActionElse     Action = "else"     // introduces a fallback, e.g. "On 10m else Off after 30s"
+	ActionAfter    Action = "after"    // precedes how long the fallback waits for another command
+	ActionPrevious Action = "Previous" // as a fallback, whatever the Relay was doing before the command
+)
+
+// State is a Relay's state as StateString reports it
+type State string
+
+// States reported by a Relay's StateString
+const (
+	StateOn  State = "ON"
+	StateOff State = "OFF"
+)
+
+// Actions returns the forms of action a Relay understands, for discovery by integrations and help texts
+func Actions() []Action {
+	return []Action{
+		ActionOn,
+		ActionOn + " <duration>",
+		ActionOn + " " + ActionIndefinitely,
+		ActionOff,
+		ActionToggle,
+		ActionPulse + " <duration>",
+		"<" + ActionOn + "|" + ActionOff + "> " + ActionElse + " <" + ActionOn + "|" + ActionOff + "|" + ActionPrevious + "> " + ActionAfter + " <duration>",
+		ActionAnnounce,
+		SettingDefaultDuration + " <duration>",
+		SettingMaxOn + " <duration>",
+		SettingDutyBudget + " <duration>",
+		SettingName + " <name>",
+		ActionValidate + " <action>",
+	}
+}
@@ -0,0 +1,66 @@
+package core
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Announce has each Relay report itself to reportCh once it is ready: its name, the state of its
+// load, any run resumed after a reset and the hash of its configuration, so a backend learns the
+// device's relay inventory after every boot. Call it after Configure and Resume; the announcements
+// queue behind any resumed runs, so they report them.
+func Announce(reportCh chan Trigger, relays ...Relay) {
+	for _, r := range relays {
+		r.Execute(Trigger{Target: r.Name(), Action: ActionAnnounce, ReportCh: reportCh, Source: SourceInternal})
+	}
+}
+
+// announce reports the Relay's inventory entry
+func (r *relay) announce(t Trigger) {
+	left, resumed := r.Remaining()
+	r.report(t, Report{
+		Kind:       ReportAnnounce,
+		On:         r.Load(),
+		Resumed:    resumed,
+		Duration:   left,
+		ConfigHash: r.Config().Hash(),
+	})
+}
+
+// Hash returns an FNV-1a hash of the configuration, so a backend can tell whether a device's relays
+// are set up as expected without comparing every field
+func (c RelayConfig) Hash() uint32 {
+	ss := strings.Builder{}
+	ss.Grow(128)
+	ss.WriteString(c.Name + "|" + c.Output)
+	for _, b := range []bool{c.ActiveLow, c.NormallyClosed, c.Fused, c.Retrigger, c.Rollover} {
+		ss.WriteString("|" + strconv.FormatBool(b))
+	}
+	for _, f := range []float32{c.FuseLimit, c.LoadPower} {
+		ss.WriteString("|" + strconv.FormatFloat(float64(f), 'g', -1, 32))
+	}
+	for _, d := range []time.Duration{c.FuseGrace, c.DefaultDuration, c.MaxOn, c.DutyBudget, c.MinOn, c.Reconcile, c.LocalOverride, c.Diagnostics, c.Repulse, c.Quiet.From, c.Quiet.To} {
+		ss.WriteString("|" + d.String())
+	}
+	ss.WriteString("|" + strconv.Itoa(int(c.MinOnPolicy)) + "|" + strconv.Itoa(int(c.ShedPriority)) + "|" + strconv.Itoa(int(c.Quiet.Policy)) + "|" + strconv.Itoa(int(c.Footprint)) + "|" + strconv.Itoa(int(c.PeakPolicy)))
+	remote := append([]string(nil), c.Remote...)
+	sort.Strings(remote)
+	ss.WriteString("|" + strings.Join(remote, ","))
+	zones := append([]string(nil), c.EStopZones...)
+	sort.Strings(zones)
+	ss.WriteString("|" + strings.Join(zones, ","))
+	keys := make([]string, 0, len(c.Tags))
+	for k := range c.Tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		ss.WriteString("|" + k + "=" + c.Tags[k])
+	}
+	h := fnv.New32a()
+	h.Write([]byte(ss.String()))
+	return h.Sum32()
+}
@@ -0,0 +1,65 @@
+package core
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Sign returns t with its Message set to the signature Authenticate checks: the time of signing in
+// Unix milliseconds and an HMAC-SHA256, under key, of that time with t's Target, Action and Duration
+func Sign(t Trigger, key []byte) Trigger {
+	ms := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	t.Message = ms + " " + hex.EncodeToString(mac(t, ms, key))
+	return t
+}
+
+// mac is the HMAC-SHA256 of t's command, signed at ms, under key
+func mac(t Trigger, ms string, key []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(ms + "\n" + t.Target + "\n" + string(t.Action) + "\n" + strconv.FormatInt(int64(t.Duration), 10)))
+	return h.Sum(nil)
+}
+
+// Authenticate refuses Triggers not signed with key by Sign, signed more than window ago (or ahead),
+// or replayed, for transports where anyone on the network could otherwise switch a dangerous load.
+// Each signature is accepted once; the clocks of sender and Relay must agree to within window.
+func Authenticate(key []byte, window time.Duration) Middleware {
+	var mu sync.Mutex
+	seen := map[string]time.Time{} // MACs accepted within the last window, by value rather than hex so case can't disguise a replay, and when they were signed
+	return Guard(func(t Trigger) string {
+		ms, sig, ok := strings.Cut(t.Message, " ")
+		if !ok {
+			return "unauthenticated, not signed"
+		}
+		n, err := strconv.ParseInt(ms, 10, 64)
+		if err != nil {
+			return "unauthenticated, bad signing time"
+		}
+		got, err := hex.DecodeString(sig)
+		if err != nil || !hmac.Equal(got, mac(t, ms, key)) {
+			return "unauthenticated, bad signature"
+		}
+		signed := time.UnixMilli(n)
+		now := time.Now()
+		if age := now.Sub(signed); age > window || age < -window {
+			return "unauthenticated, signed outside its " + window.String() + " window"
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for s, at := range seen {
+			if now.Sub(at) > window {
+				delete(seen, s)
+			}
+		}
+		if _, ok := seen[string(got)]; ok {
+			return "unauthenticated, a replay"
+		}
+		seen[string(got)] = signed
+		return ""
+	})
+}
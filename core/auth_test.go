@@ -0,0 +1,33 @@
+package core_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eyelight/relay/core"
+	"github.com/eyelight/relay/relaytest"
+)
+
+func TestAuthenticateRefusesReplays(t *testing.T) {
+	key := []byte("secret")
+	var passed int
+	h := core.Authenticate(key, time.Minute)(func(core.Trigger) { passed++ })
+	rec := relaytest.NewRecorder()
+	signed := core.Sign(core.Trigger{Target: "gate", Action: core.ActionOn, ReportCh: rec.C()}, key)
+	ms, sig, _ := strings.Cut(signed.Message, " ")
+
+	h(signed)
+	if passed != 1 {
+		t.Fatalf("signed Trigger passed %d times, want 1", passed)
+	}
+	for _, replay := range []string{sig, strings.ToUpper(sig), strings.ToUpper(sig[:1]) + sig[1:]} {
+		t2 := signed
+		t2.Message = ms + " " + replay
+		h(t2)
+		rec.Expect(t, "a replay", time.Second)
+	}
+	if passed != 1 {
+		t.Errorf("replays passed %d times, want none", passed-1)
+	}
+}
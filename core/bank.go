@@ -0,0 +1,67 @@
+package core
+
+import "sync"
+
+// Bank is a set of Relays on one board, addressable by channel number or name
+type Bank struct {
+	name   string
+	relays []Relay
+	mu     sync.Mutex // guards byName, which follows renamed Relays
+	byName map[string]int
+}
+
+// NewBank returns a Bank of the given Relays in channel order
+func NewBank(name string, relays ...Relay) *Bank {
+	b := &Bank{
+		name:   name,
+		relays: relays,
+		byName: make(map[string]int, len(relays)),
+	}
+	for i, r := range relays {
+		b.byName[r.Name()] = i
+		i := i
+		r.WatchName(func(old, name string) {
+			b.mu.Lock()
+			delete(b.byName, old)
+			b.byName[name] = i
+			b.mu.Unlock()
+		})
+	}
+	return b
+}
+
+// Configure configures every Relay in the Bank, leaving them all off
+func (b *Bank) Configure() {
+	for _, r := range b.relays {
+		r.Configure()
+	}
+}
+
+// Channel returns the Relay on channel n, counting from 1 as relay boards are labelled, or nil
+func (b *Bank) Channel(n int) Relay {
+	if n < 1 || n > len(b.relays) {
+		return nil
+	}
+	return b.relays[n-1]
+}
+
+// ByName returns the Relay with the given channel name, or nil
+func (b *Bank) ByName(name string) Relay {
+	b.mu.Lock()
+	i, ok := b.byName[name]
+	b.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	return b.relays[i]
+}
+
+// Relays returns the Bank's Relays in channel order
+func (b *Bank) Relays() []Relay {
+	return b.relays
+}
+
+// Name returns the Bank's name, e.g. the board layout it was built from
+func (b *Bank) Name() string {
+	return b.name
+}
@@ -0,0 +1,84 @@
+package core
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Cascade declares what follows a Relay's transition – e.g. after the pump switches off, wait 5s,
+// then flush the valve for 30s – as data rather than Watch callbacks. A Scheduler runs it.
+type Cascade struct {
+	Name  string // identifies the Cascade in reports
+	After Relay  // the Relay whose transition sets the Cascade off
+	On    bool   // which transition: true for switching on, false for switching off
+	Steps []CascadeStep
+}
+
+// CascadeStep is one link of a Cascade
+type CascadeStep struct {
+	Delay    time.Duration // after the previous step, or the transition for the first
+	Relay    Relay
+	Action   Action        // ActionOn or ActionOff
+	Duration time.Duration // for "On", how long to run; 0 is the Relay's default
+}
+
+// AddCascade has the Scheduler run c's steps whenever c.After makes c's transition, reporting each
+// step on the Scheduler's report channel. Any later transition of c.After interrupts a Cascade
+// still waiting on a delay; steps already taken stand.
+func (sc *Scheduler) AddCascade(c Cascade) {
+	var mu sync.Mutex
+	var stop chan struct{}
+	c.After.Watch(func(on bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		if stop != nil {
+			close(stop)
+			stop = nil
+		}
+		if on != c.On {
+			return
+		}
+		stop = make(chan struct{})
+		go sc.cascade(c, stop)
+	})
+}
+
+// cascade takes c's steps in turn until they are done or stop is closed
+func (sc *Scheduler) cascade(c Cascade, stop <-chan struct{}) {
+	n := strconv.Itoa(len(c.Steps))
+	for i, s := range c.Steps {
+		t := time.NewTimer(s.Delay)
+		select {
+		case <-t.C:
+		case <-stop:
+			t.Stop()
+			post(sc.reportCh, c.After, Report{
+				Relay:  c.Name,
+				Action: "Cascade",
+				Kind:   ReportProgress,
+				Detail: "cascade interrupted before step " + strconv.Itoa(i+1) + "/" + n + " by " + c.After.Name() + " switching again",
+			})
+			return
+		}
+		post(sc.reportCh, s.Relay, Report{
+			Relay:  c.Name,
+			Action: "Cascade",
+			Kind:   ReportProgress,
+			Detail: "cascade step " + strconv.Itoa(i+1) + "/" + n + ": " + s.Relay.Name() + " " + string(s.Action),
+		})
+		s.Relay.Execute(Trigger{
+			Target:   s.Relay.Name(),
+			Action:   s.Action,
+			Duration: s.Duration,
+			ReportCh: sc.reportCh,
+			Source:   SourceSchedule,
+		})
+	}
+	post(sc.reportCh, c.After, Report{
+		Relay:  c.Name,
+		Action: "Cascade",
+		Kind:   ReportProgress,
+		Detail: "cascade finished, " + n + " steps",
+	})
+}
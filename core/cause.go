@@ -0,0 +1,56 @@
+package core
+
+// Cause says why a Relay made a transition, or on whose behalf a command was accepted
+type Cause uint8
+
+const (
+	CauseDirect     Cause = iota // the On, Off or Set methods were called, e.g. by a TPO engine or Mirror
+	CauseCommand                 // a Trigger
+	CauseSchedule                // a scheduled run
+	CauseTimer                   // a timed run expired
+	CauseInterlock               // an interlock, e.g. a Prerequisite switching off
+	CauseThermal                 // a thermal trip
+	CauseEStop                   // an emergency stop
+	CauseFuse                    // the soft fuse blew
+	CauseShed                    // load shedding
+	CauseReset                   // a ForceReset
+	CauseDiagnostic              // a driver's diagnostics
+)
+
+// String returns the Cause's name for use in reports
+func (c Cause) String() string {
+	switch c {
+	case CauseDirect:
+		return "direct"
+	case CauseCommand:
+		return "command"
+	case CauseSchedule:
+		return "schedule"
+	case CauseTimer:
+		return "timer"
+	case CauseInterlock:
+		return "interlock"
+	case CauseThermal:
+		return "thermal"
+	case CauseEStop:
+		return "e-stop"
+	case CauseFuse:
+		return "fuse"
+	case CauseShed:
+		return "shed"
+	case CauseReset:
+		return "reset"
+	case CauseDiagnostic:
+		return "diagnostic"
+	default:
+		return "unknown"
+	}
+}
+
+// causeOf returns the Cause of what a Trigger from s leads to
+func causeOf(s Source) Cause {
+	if s == SourceSchedule {
+		return CauseSchedule
+	}
+	return CauseCommand
+}
@@ -0,0 +1,32 @@
+package core_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eyelight/relay/core"
+	"github.com/eyelight/relay/relaytest"
+)
+
+func TestCauseFollowsSource(t *testing.T) {
+	for _, c := range []struct {
+		source core.Source
+		want   core.Cause
+	}{
+		{core.SourceSchedule, core.CauseSchedule},
+		{core.SourceRemote, core.CauseCommand},
+		{core.SourceButton, core.CauseCommand},
+	} {
+		r := core.New(relaytest.NewOutput(), "valve")
+		r.Configure()
+		r.Execute(core.Trigger{Target: "valve", Action: core.ActionOn, Duration: time.Hour, Source: c.source})
+		waitFor(t, "the transition", func() bool { return r.Seq() >= 2 })
+		h := r.History()
+		last := h[len(h)-1]
+		if last.Kind != core.EntryTransition || last.Cause != c.want || last.Source != c.source {
+			t.Errorf("from %v: last entry %+v, want a transition caused by %v", c.source, last, c.want)
+		}
+		r.Execute(core.Trigger{Target: "valve", Action: core.ActionOff, Source: c.source})
+		waitFor(t, "Off", func() bool { _, ok := r.Remaining(); return !ok })
+	}
+}
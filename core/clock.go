@@ -0,0 +1,103 @@
+package core
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Ticks returns the time elapsed since a fixed point such as boot, from a counter that runs whatever
+// the wall clock does, e.g. milliseconds counted by a SysTick interrupt
+type Ticks func() time.Duration
+
+// clock is where a Relay's times come from
+type clock struct {
+	ticks Ticks     // nil uses time.Now
+	epoch time.Time // the wall time at tick 0; zero if it isn't known
+}
+
+// clk holds the clock set by SetTickSource
+var clk atomic.Value
+
+// Clock is a source of time that also runs timers, such as relaytest's fake Clock
+type Clock interface {
+	Now() time.Time
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is a call pending on a Clock; a *time.Timer made by time.AfterFunc is one
+type Timer interface {
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// clockHolder lets an atomic.Value hold a nil Clock
+type clockHolder struct {
+	c Clock
+}
+
+// ext holds the Clock set by SetClock
+var ext atomic.Value
+
+// SetClock has package core take the time from c and run its Relays' timers – run expiry, minimum
+// on-time, fallbacks, Prerequisites, Mirror and Purge delays – on it, so that a test can step a
+// Relay through hours in microseconds. Schedules, quiet hours and tariffs keep to the wall clock, and
+// short settling sleeps stay real. A nil c restores time.Now, or the tick source.
+func SetClock(c Clock) {
+	ext.Store(clockHolder{c: c})
+}
+
+// external returns the Clock set by SetClock, or nil
+func external() Clock {
+	h, _ := ext.Load().(clockHolder)
+	return h.c
+}
+
+// afterFunc calls f in its own goroutine after d by the Clock set with SetClock, or by the real clock
+func afterFunc(d time.Duration, f func()) Timer {
+	if c := external(); c != nil {
+		return c.AfterFunc(d, f)
+	}
+	return time.AfterFunc(d, f)
+}
+
+// tickBase stands for tick 0 when the wall time isn't known, so that times stay non-zero and
+// epoch-based encodings such as CompactFormatter's carry the time since tick 0
+var tickBase = time.Unix(0, 0)
+
+// SetTickSource bases all of package core's elapsed-time math and report timestamps on t, for targets
+// whose time.Now doesn't work. epoch is the wall time at tick 0, if known, e.g. from an RTC; while it
+// is zero, reports are stamped with the time since tick 0, e.g. "at +1h2m3s", rather than a date.
+// Timers and sleeps still run on the target's own timekeeping, and Schedules and quiet hours, which
+// are times of day, need a working time.Now. Call it before creating Relays; a nil t restores time.Now.
+func SetTickSource(t Ticks, epoch time.Time) {
+	clk.Store(clock{ticks: t, epoch: epoch})
+}
+
+// now returns the current time by the Clock, the tick source, or time.Now
+func now() time.Time {
+	if x := external(); x != nil {
+		return x.Now()
+	}
+	c, _ := clk.Load().(clock)
+	if c.ticks == nil {
+		return time.Now()
+	}
+	if c.epoch.IsZero() {
+		return tickBase.Add(c.ticks())
+	}
+	return c.epoch.Add(c.ticks())
+}
+
+// since returns the time elapsed since t, by the tick source or time.Now
+func since(t time.Time) time.Duration {
+	return now().Sub(t)
+}
+
+// stamp formats t for reports: as a local date and time, or as the time since tick 0 when a tick
+// source without a known epoch is in use
+func stamp(t time.Time) string {
+	if c, _ := clk.Load().(clock); c.ticks != nil && c.epoch.IsZero() && external() == nil {
+		return "+" + t.Sub(tickBase).String()
+	}
+	return t.Local().Format(time.RFC822)
+}
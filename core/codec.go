@@ -0,0 +1,149 @@
+package core
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"time"
+)
+
+// Compact binary frames for bandwidth-constrained transports such as LoRa and CAN. Multi-byte
+// fields are little-endian and Relays are addressed by their index in a list both ends agree on.
+//
+//	command: target index (1) | action code (1) | duration in seconds (4)
+//	state:   relay index (1) | flags (1) | fault (1) | seconds in state (4) | seq (4)
+const (
+	CommandFrameLen = 6
+	StateFrameLen   = 11
+)
+
+// ActionCode is the one-byte form of a Trigger's Action
+type ActionCode uint8
+
+const (
+	CodeOn ActionCode = iota + 1
+	CodeOff
+	CodeSetDefaultDuration
+	CodeSetMaxOn
+	CodeSetDutyBudget
+)
+
+const stateFlagOn = 1 << 0
+
+var (
+	ErrShortFrame    = errors.New("relay: frame too short")
+	ErrUnknownAction = errors.New("relay: action has no binary code")
+	ErrUnknownTarget = errors.New("relay: target index out of range")
+	ErrFrameDuration = errors.New("relay: duration too long for a frame")
+)
+
+var actionCodes = map[Action]ActionCode{
+	ActionOn:               CodeOn,
+	"on":                   CodeOn,
+	"ON":                   CodeOn,
+	ActionOff:              CodeOff,
+	"off":                  CodeOff,
+	"OFF":                  CodeOff,
+	SettingDefaultDuration: CodeSetDefaultDuration,
+	SettingMaxOn:           CodeSetMaxOn,
+	SettingDutyBudget:      CodeSetDutyBudget,
+}
+
+var codeActions = map[ActionCode]Action{
+	CodeOn:                 ActionOn,
+	CodeOff:                ActionOff,
+	CodeSetDefaultDuration: SettingDefaultDuration,
+	CodeSetMaxOn:           SettingMaxOn,
+	CodeSetDutyBudget:      SettingDutyBudget,
+}
+
+// StateFrame is a decoded state report
+type StateFrame struct {
+	Index   uint8
+	On      bool
+	Fault   Fault
+	Seconds uint32 // time spent in the current state
+	Seq     uint32
+}
+
+// EncodeCommand writes t as a command frame for the Relay at index into b, which must hold CommandFrameLen bytes.
+// Durations are carried in whole seconds, a part second rounded up so a short run never reads as an
+// indefinite one; durations of more than math.MaxUint32 seconds give ErrFrameDuration.
+func EncodeCommand(b []byte, index uint8, t Trigger) error {
+	if len(b) < CommandFrameLen {
+		return ErrShortFrame
+	}
+	code, ok := actionCodes[t.Action]
+	if !ok {
+		return ErrUnknownAction
+	}
+	secs := t.Duration / time.Second
+	if t.Duration%time.Second > 0 {
+		secs++
+	}
+	if secs < 0 {
+		secs = 0
+	}
+	if secs > math.MaxUint32 {
+		return ErrFrameDuration
+	}
+	b[0] = index
+	b[1] = uint8(code)
+	binary.LittleEndian.PutUint32(b[2:6], uint32(secs))
+	return nil
+}
+
+// DecodeCommand reads a command frame into a Trigger addressed to names[index], ready to be
+// given a ReportCh and dispatched
+func DecodeCommand(b []byte, names []string) (Trigger, error) {
+	if len(b) < CommandFrameLen {
+		return Trigger{}, ErrShortFrame
+	}
+	if int(b[0]) >= len(names) {
+		return Trigger{}, ErrUnknownTarget
+	}
+	action, ok := codeActions[ActionCode(b[1])]
+	if !ok {
+		return Trigger{}, ErrUnknownAction
+	}
+	return Trigger{
+		Target:   names[b[0]],
+		Action:   action,
+		Duration: time.Duration(binary.LittleEndian.Uint32(b[2:6])) * time.Second,
+	}, nil
+}
+
+// EncodeState writes r's current state as a state frame for index into b, which must hold StateFrameLen bytes
+func EncodeState(b []byte, index uint8, r Relay) error {
+	if len(b) < StateFrameLen {
+		return ErrShortFrame
+	}
+	on, at := r.State()
+	var secs uint32
+	if !at.IsZero() {
+		secs = uint32(since(at) / time.Second)
+	}
+	b[0] = index
+	b[1] = 0
+	if on.(bool) {
+		b[1] |= stateFlagOn
+	}
+	b[2] = uint8(r.Fault())
+	binary.LittleEndian.PutUint32(b[3:7], secs)
+	binary.LittleEndian.PutUint32(b[7:11], r.Seq())
+	return nil
+}
+
+// DecodeState reads a state frame
+func DecodeState(b []byte) (StateFrame, error) {
+	if len(b) < StateFrameLen {
+		return StateFrame{}, ErrShortFrame
+	}
+	return StateFrame{
+		Index:   b[0],
+		On:      b[1]&stateFlagOn != 0,
+		Fault:   Fault(b[2]),
+		Seconds: binary.LittleEndian.Uint32(b[3:7]),
+		Seq:     binary.LittleEndian.Uint32(b[7:11]),
+	}, nil
+}
@@ -0,0 +1,39 @@
+package core_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eyelight/relay/core"
+)
+
+func TestCommandFrameDurations(t *testing.T) {
+	names := []string{"pump"}
+	for _, c := range []struct {
+		in, want time.Duration
+		err      error
+	}{
+		{in: 0, want: 0},
+		{in: 30 * time.Second, want: 30 * time.Second},
+		{in: 500 * time.Millisecond, want: time.Second},
+		{in: 1500 * time.Millisecond, want: 2 * time.Second},
+		{in: 200 * 365 * 24 * time.Hour, err: core.ErrFrameDuration},
+	} {
+		b := make([]byte, core.CommandFrameLen)
+		err := core.EncodeCommand(b, 0, core.Trigger{Target: "pump", Action: core.ActionOn, Duration: c.in})
+		if err != c.err {
+			t.Errorf("EncodeCommand(%v) = %v, want %v", c.in, err, c.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		got, err := core.DecodeCommand(b, names)
+		if err != nil {
+			t.Fatalf("DecodeCommand: %v", err)
+		}
+		if got.Duration != c.want {
+			t.Errorf("%v round-tripped as %v, want %v", c.in, got.Duration, c.want)
+		}
+	}
+}
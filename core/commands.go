@@ -0,0 +1,44 @@
+package core
+
+import (
+	"time"
+)
+
+// Command describes a Trigger received by a Relay
+type Command struct {
+	Action   Action
+	Duration time.Duration
+	Time     time.Time
+	Source   Source
+}
+
+// CommandStats counts the Triggers a Relay has handled: accepted ones were carried out (or deferred),
+// rejected ones were understood but refused by policy (fault, budget, minimum on-time, allow-list),
+// and errored ones could not be understood (wrong target, unknown action, unparsable value)
+type CommandStats struct {
+	Accepted uint32
+	Rejected uint32
+	Errored  uint32
+	Last     Command // the most recent Trigger received, whatever became of it
+}
+
+// Commands returns the Relay's command counters and the last command it received
+func (r *relay) Commands() CommandStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.commands
+}
+
+// received records t as the last command received, and its Source as that of the commands and
+// transitions it leads to
+func (r *relay) received(t Trigger) {
+	r.mu.Lock()
+	r.commands.Last = Command{
+		Action:   t.Action,
+		Duration: t.Duration,
+		Time:     now(),
+		Source:   t.Source,
+	}
+	r.source = t.Source
+	r.mu.Unlock()
+}
@@ -0,0 +1,62 @@
+package core
+
+import (
+	"strconv"
+	"time"
+)
+
+// CompactFormatter renders Reports as space-separated key=value pairs with times in Unix epoch
+// milliseconds and durations in milliseconds, e.g.
+// "Pump kind=1 t=1700000000000 d=300000 seq=12 cause=command". It avoids formatting dates,
+// which is slow and allocates heavily on small targets, and suits consumers that parse reports.
+// Fields that don't apply to the Report's kind are left out.
+func CompactFormatter(rep Report) string {
+	b := make([]byte, 0, 96)
+	b = append(b, rep.Relay...)
+	b = append(b, " kind="...)
+	b = strconv.AppendUint(b, uint64(rep.Kind), 10)
+	if rep.Error {
+		b = append(b, " err=1"...)
+	}
+	b = append(b, " t="...)
+	b = strconv.AppendInt(b, rep.Time.UnixNano()/int64(time.Millisecond), 10)
+	switch rep.Kind {
+	case ReportOn, ReportDuration, ReportSetting, ReportAnnounce:
+		b = append(b, " d="...)
+		b = strconv.AppendInt(b, int64(rep.Duration/time.Millisecond), 10)
+	}
+	switch rep.Kind {
+	case ReportOff, ReportForcedOff, ReportDuration, ReportFault:
+		b = append(b, " elapsed="...)
+		b = strconv.AppendInt(b, int64(rep.Elapsed/time.Millisecond), 10)
+	}
+	switch rep.Kind {
+	case ReportDuration:
+		b = append(b, " prev="...)
+		b = strconv.AppendInt(b, int64(rep.Previous/time.Millisecond), 10)
+	case ReportFault:
+		b = append(b, " fault="...)
+		b = append(b, rep.Fault.String()...)
+	case ReportSetting:
+		b = append(b, " setting="...)
+		b = append(b, rep.Setting...)
+	case ReportAnnounce:
+		b = append(b, " on="...)
+		b = strconv.AppendBool(b, rep.On)
+		b = append(b, " config="...)
+		b = strconv.AppendUint(b, uint64(rep.ConfigHash), 16)
+	}
+	if rep.Seq > 0 {
+		b = append(b, " seq="...)
+		b = strconv.AppendUint(b, uint64(rep.Seq), 10)
+		b = append(b, " cause="...)
+		b = append(b, rep.Cause.String()...)
+		b = append(b, " src="...)
+		b = append(b, rep.Source.String()...)
+	}
+	if rep.Detail != "" {
+		b = append(b, " msg="...)
+		b = strconv.AppendQuote(b, rep.Detail)
+	}
+	return string(b)
+}
@@ -0,0 +1,77 @@
+package core
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// RelayConfig is a snapshot of a Relay's effective settings, for display and verification by management tooling
+type RelayConfig struct {
+	Name            string
+	Output          string // describes what drives the Relay, e.g. "GPIO 2"
+	ActiveLow       bool
+	NormallyClosed  bool
+	Fused           bool
+	FuseLimit       float32       // amps
+	FuseGrace       time.Duration // how long FuseLimit may be exceeded
+	DefaultDuration time.Duration
+	MaxOn           time.Duration
+	DutyBudget      time.Duration // per 24 hours
+	Remote          []string      // settings that may be changed through Triggers
+	MinOn           time.Duration
+	MinOnPolicy     MinOnPolicy
+	Tags            map[string]string
+	ShedPriority    uint8
+	Reconcile       time.Duration // how often the intended state is re-asserted; 0 if never
+	Retrigger       bool          // a timed "On" during a run restarts its countdown
+	LoadPower       float32       // watts, for energy estimates
+	Rollover        bool          // the daily counters reset at midnight
+	LocalOverride   time.Duration // how long a local Off holds off "On" from other sources
+	Diagnostics     time.Duration // how often the Output's diagnostics are polled; 0 if never
+	Repulse         time.Duration // how often the drive is refreshed while on; 0 if never
+	Quiet           Quiet
+	Footprint       Footprint // set by SetFootprint when the Relay was created
+	EStopZones      []string
+	PeakPolicy      PeakPolicy
+}
+
+// Config returns the Relay's effective settings
+func (r *relay) Config() RelayConfig {
+	c := RelayConfig{
+		Output:       describe(r.out),
+		Tags:         r.Tags(),
+		ShedPriority: r.ShedPriority(),
+	}
+	r.mu.Lock()
+	c.Name = r.Name()
+	c.ActiveLow = atomic.LoadUint32(&r.activeLow) == 1
+	c.NormallyClosed = atomic.LoadUint32(&r.nc) == 1
+	c.DefaultDuration = r.defaultDuration
+	c.MaxOn = r.maxOn
+	c.DutyBudget = r.dutyBudget
+	c.MinOn = r.minOn
+	c.MinOnPolicy = r.minOnPolicy
+	c.Retrigger = r.retrigger
+	c.LoadPower = r.watts
+	c.LocalOverride = r.localOverride
+	c.Quiet = r.quiet
+	c.Footprint = r.footprint
+	c.PeakPolicy = r.peakPolicy
+	c.Reconcile = r.reconcileEvery
+	c.Rollover = r.rollover != nil
+	c.Diagnostics = r.diagnoseEvery
+	c.Repulse = r.repulseEvery
+	c.EStopZones = append([]string(nil), r.zones...)
+	for s := range r.remote {
+		c.Remote = append(c.Remote, s)
+	}
+	f := r.fuse
+	r.mu.Unlock()
+
+	if f != nil {
+		c.Fused = true
+		c.FuseLimit = f.limit
+		c.FuseGrace = f.grace
+	}
+	return c
+}
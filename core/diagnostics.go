@@ -0,0 +1,46 @@
+package core
+
+import "time"
+
+// Diagnoser is implemented by Outputs whose driver can diagnose the load and itself – open load,
+// short circuit, overtemperature – such as smart high-side drivers. A Relay on such an Output
+// translates what it reports into Faults, the same way whatever the driver.
+type Diagnoser interface {
+	// Diagnose returns the Fault the driver currently reports, or NoFault
+	Diagnose() Fault
+}
+
+// SetDiagnostics polls the Relay's Output for diagnostics every interval, if it is a Diagnoser,
+// and trips the Relay on any Fault it reports. 0 stops polling. Drivers that signal faults
+// themselves, e.g. by interrupt, can call Trip instead.
+func (r *relay) SetDiagnostics(interval time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.diagnoseStop != nil {
+		close(r.diagnoseStop)
+		r.diagnoseStop = nil
+	}
+	d, ok := r.out.(Diagnoser)
+	r.diagnoseEvery = 0
+	if ok && interval > 0 {
+		r.diagnoseEvery = interval
+		r.diagnoseStop = make(chan struct{})
+		go r.diagnose(d, interval, r.diagnoseStop)
+	}
+}
+
+// diagnose polls d every interval until stop is closed
+func (r *relay) diagnose(d Diagnoser, interval time.Duration, stop chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if f := d.Diagnose(); f != NoFault && r.Fault() == NoFault {
+				r.Trip(f, describe(r.out)+" reports "+f.String())
+			}
+		}
+	}
+}
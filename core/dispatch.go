@@ -0,0 +1,112 @@
+package core
+
+import "sync"
+
+// UnknownPolicy says what a Dispatcher does with a Trigger for a target it doesn't know
+type UnknownPolicy uint8
+
+const (
+	UnknownReport     UnknownPolicy = iota // report an error for every such Trigger
+	UnknownReportOnce                      // report an error for the first Trigger to each unknown target, and drop the rest
+	UnknownDrop                            // drop it silently
+	UnknownForward                         // hand it to the catch-all Handler, e.g. to log it or forward it to another controller
+)
+
+// unknownReportedMax caps the unknown targets an UnknownReportOnce Dispatcher remembers; past it,
+// it forgets them all and each may be reported once more
+const unknownReportedMax = 64
+
+// DispatcherName is the name a Dispatcher answers to, so it can itself be wrapped and dispatched to
+const DispatcherName = "Dispatcher"
+
+// Dispatcher routes Triggers to Executors by target name, so a mis-targeted Trigger is handled
+// by policy in one place instead of drawing an error report from whichever Relay received it.
+// Relays it routes to are followed through renames.
+type Dispatcher struct {
+	mu       sync.Mutex // guards byName, policy, catchAll & reported
+	byName   map[string]Executor
+	policy   UnknownPolicy
+	catchAll Handler
+	reported map[string]bool
+}
+
+// NewDispatcher returns a Dispatcher over xs that reports Triggers for unknown targets as errors
+func NewDispatcher(xs ...Executor) *Dispatcher {
+	d := &Dispatcher{
+		byName:   make(map[string]Executor, len(xs)),
+		reported: make(map[string]bool),
+	}
+	for _, x := range xs {
+		d.Add(x)
+	}
+	return d
+}
+
+// Add routes Triggers for x's name to x, replacing any Executor of that name
+func (d *Dispatcher) Add(x Executor) {
+	d.mu.Lock()
+	d.byName[x.Name()] = x
+	delete(d.reported, x.Name())
+	d.mu.Unlock()
+	if r, ok := x.(Relay); ok {
+		r.WatchName(func(old, name string) {
+			d.mu.Lock()
+			if d.byName[old] == x {
+				delete(d.byName, old)
+			}
+			d.byName[name] = x
+			delete(d.reported, name)
+			d.mu.Unlock()
+		})
+	}
+}
+
+// SetUnknown sets what happens to Triggers for unknown targets; catchAll is only used, and must
+// be given, with UnknownForward
+func (d *Dispatcher) SetUnknown(p UnknownPolicy, catchAll Handler) {
+	d.mu.Lock()
+	d.policy, d.catchAll = p, catchAll
+	d.reported = make(map[string]bool)
+	d.mu.Unlock()
+}
+
+// Name returns DispatcherName
+func (d *Dispatcher) Name() string {
+	return DispatcherName
+}
+
+// Execute hands t to the Executor named by its target, or deals with it by the UnknownPolicy
+func (d *Dispatcher) Execute(t Trigger) {
+	d.mu.Lock()
+	x, ok := d.byName[t.Target]
+	policy, catchAll := d.policy, d.catchAll
+	first := !d.reported[t.Target]
+	if !ok && policy == UnknownReportOnce && first {
+		if len(d.reported) >= unknownReportedMax {
+			d.reported = make(map[string]bool)
+		}
+		d.reported[t.Target] = true
+	}
+	d.mu.Unlock()
+	if ok {
+		x.Execute(t)
+		return
+	}
+	switch policy {
+	case UnknownDrop:
+		return
+	case UnknownReportOnce:
+		if !first {
+			return
+		}
+	case UnknownForward:
+		if catchAll != nil {
+			catchAll(t)
+			return
+		}
+	}
+	t.Error = true
+	t.Severity = SeverityWarning
+	t.Message = "error - no target named " + t.Target + " for '" + string(t.Action) + "'"
+	reply(t)
+}
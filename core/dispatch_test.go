@@ -0,0 +1,34 @@
+package core_test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/eyelight/relay/core"
+	"github.com/eyelight/relay/relaytest"
+)
+
+func TestDispatcherForgetsUnknownTargets(t *testing.T) {
+	d := core.NewDispatcher()
+	d.SetUnknown(core.UnknownReportOnce, nil)
+	rec := relaytest.NewRecorder()
+	send := func(target string) {
+		d.Execute(core.Trigger{Target: target, Action: core.ActionOn, ReportCh: rec.C()})
+	}
+	send("ghost0")
+	send("ghost0")
+	if n := len(rec.Wait(t, 1, time.Second)); n != 1 {
+		t.Fatalf("%d reports for one unknown target, want 1", n)
+	}
+	for i := 1; i <= 64; i++ { // a stream of distinct targets, e.g. from a misbehaving sender
+		send("ghost" + strconv.Itoa(i))
+	}
+	send("ghost0")
+	rec.Wait(t, 66, time.Second)
+	send("ghost0")
+	time.Sleep(10 * time.Millisecond)
+	if n := len(rec.Reports()); n != 66 {
+		t.Errorf("%d reports, want 66: ghost0 once before and once after the set was cleared", n)
+	}
+}
@@ -0,0 +1,18 @@
+package core
+
+import "time"
+
+// MaxDuration is the longest run an "On" Trigger may ask for. Longer durations – typically a
+// garbled number from a text protocol – are refused rather than taken at face value.
+const MaxDuration = 90 * 24 * time.Hour
+
+// indefinite stands for the duration of an "On indefinitely"; it never appears in a Trigger
+const indefinite time.Duration = -1
+
+// durationString describes the duration of a run, which is indefinite if not positive
+func durationString(d time.Duration) string {
+	if d <= 0 {
+		return "indefinitely"
+	}
+	return d.String()
+}
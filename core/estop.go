@@ -0,0 +1,236 @@
+package core
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// EStopName is the name an EStop answers to as a Trigger target
+const EStopName = "EStop"
+
+// EStop is an emergency stop over a set of Relays, divided into zones: asserting a zone forces its
+// Relays off and refuses them "On" Triggers until it is released, while the other zones run on.
+// A Relay's zones are set with SetEStopZones; asserting the whole EStop stops every Relay, zoned or not.
+type EStop struct {
+	mu       sync.Mutex
+	relays   []*relay
+	asserted map[string]bool // zones asserted; "" is the whole EStop
+}
+
+// ZoneStatus describes one zone of an EStop
+type ZoneStatus struct {
+	Zone     string
+	Asserted bool
+	Relays   []string // the zone's members
+}
+
+// NewEStop returns an EStop over relays, with no zone asserted
+func NewEStop(relays ...Relay) *EStop {
+	e := &EStop{asserted: map[string]bool{}}
+	for _, rr := range relays {
+		r, ok := rr.(*relay)
+		if !ok {
+			continue
+		}
+		e.relays = append(e.relays, r)
+		r.mu.Lock()
+		r.estops = append(r.estops, e)
+		r.mu.Unlock()
+	}
+	return e
+}
+
+// SetEStopZones puts the Relay in the named zones of any EStop it belongs to, replacing its previous zones
+func (r *relay) SetEStopZones(zones ...string) {
+	r.mu.Lock()
+	r.zones = append([]string(nil), zones...)
+	r.mu.Unlock()
+}
+
+// inZone reports whether the Relay is stopped by asserting zone; every Relay is in the whole EStop's zone ""
+func (r *relay) inZone(zone string) bool {
+	if zone == "" {
+		return true
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, z := range r.zones {
+		if z == zone {
+			return true
+		}
+	}
+	return false
+}
+
+// Assert stops zone – or, for "", every Relay of the EStop – forcing its Relays off at once
+func (e *EStop) Assert(zone string) {
+	e.mu.Lock()
+	e.asserted[zone] = true
+	relays := e.relays
+	e.mu.Unlock()
+	var members []*relay
+	for _, r := range relays {
+		if r.inZone(zone) {
+			members = append(members, r)
+		}
+	}
+	halt(members, CauseEStop)
+}
+
+// halt cuts every one of relays, then has each one's worker force it off for c, without waiting on
+// any of them: a Relay whose worker is busy or whose Output is slow can't keep the rest energized.
+// The caller latches whatever bars the Relays from switching on again first.
+func halt(relays []*relay, c Cause) {
+	for _, r := range relays {
+		if !slow(r.out) {
+			r.cut()
+		}
+	}
+	for _, r := range relays {
+		r := r
+		go func() {
+			if slow(r.out) {
+				r.cut()
+			}
+			r.do(func() {
+				r.forceOff(c)
+			})
+		}()
+	}
+}
+
+// Release lifts the stop on zone, or on the whole EStop for ""; its Relays stay off until told
+// otherwise, and those in another asserted zone stay stopped
+func (e *EStop) Release(zone string) {
+	e.mu.Lock()
+	delete(e.asserted, zone)
+	e.mu.Unlock()
+}
+
+// Asserted reports whether zone – or, for "", the whole EStop – is asserted
+func (e *EStop) Asserted(zone string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.asserted[zone]
+}
+
+// Zones returns the status of every zone the EStop's Relays belong to, and of any zone asserted
+// without members, in order of name
+func (e *EStop) Zones() []ZoneStatus {
+	e.mu.Lock()
+	members := map[string][]string{}
+	for z := range e.asserted {
+		if z != "" {
+			members[z] = nil
+		}
+	}
+	relays := e.relays
+	e.mu.Unlock()
+	for _, r := range relays {
+		r.mu.Lock()
+		for _, z := range r.zones {
+			members[z] = append(members[z], r.Name())
+		}
+		r.mu.Unlock()
+	}
+	zones := make([]ZoneStatus, 0, len(members))
+	for z, names := range members {
+		zones = append(zones, ZoneStatus{Zone: z, Asserted: e.Asserted(z), Relays: names})
+	}
+	sort.Slice(zones, func(i, j int) bool { return zones[i].Zone < zones[j].Zone })
+	return zones
+}
+
+// stopping returns the asserted zone stopping r, or false
+func (e *EStop) stopping(r *relay) (string, bool) {
+	e.mu.Lock()
+	asserted := make([]string, 0, len(e.asserted))
+	for z := range e.asserted {
+		asserted = append(asserted, z)
+	}
+	e.mu.Unlock()
+	for _, z := range asserted {
+		if r.inZone(z) {
+			return z, true
+		}
+	}
+	return "", false
+}
+
+// estopRefusal explains why a Relay stopped by an EStop refuses "On", or returns ""
+func (r *relay) estopRefusal() string {
+	r.mu.Lock()
+	estops := r.estops
+	r.mu.Unlock()
+	for _, e := range estops {
+		if z, ok := e.stopping(r); ok {
+			if z == "" {
+				return "refused On, the e-stop is asserted"
+			}
+			return "refused On, e-stop zone " + z + " is asserted"
+		}
+	}
+	return ""
+}
+
+// Name returns EStopName and along with Execute lets an EStop be dispatched to like a Relay
+func (e *EStop) Name() string {
+	return EStopName
+}
+
+// Execute asserts or releases a zone, or reports the zones' status, for Actions "Assert [zone]",
+// "Release [zone]" and "Status"; without a zone, Assert and Release apply to the whole EStop
+func (e *EStop) Execute(t Trigger) {
+	fields := strings.Fields(string(t.Action))
+	zone := ""
+	if len(fields) == 2 {
+		zone = fields[1]
+	}
+	which := "zone " + zone
+	if zone == "" {
+		which = "all zones"
+	}
+	switch {
+	case t.Target != EStopName:
+		t.Error = true
+		t.Message = "error - " + EStopName + " received a trigger intended for " + t.Target
+	case len(fields) == 0 || len(fields) > 2:
+		t.Error = true
+		t.Message = "error - " + EStopName + " does not understand Action: '" + string(t.Action) + "' (Assert [zone], Release [zone], Status)"
+	case fields[0] == "Assert":
+		e.Assert(zone)
+		t.Severity = SeveritySafety
+		t.Message = EStopName + " - asserted " + which
+	case fields[0] == "Release":
+		e.Release(zone)
+		t.Message = EStopName + " - released " + which
+	case fields[0] == "Status" && len(fields) == 1:
+		ss := strings.Builder{}
+		ss.WriteString(EStopName + " - ")
+		if e.Asserted("") {
+			ss.WriteString("asserted")
+		} else {
+			ss.WriteString("clear")
+		}
+		for _, z := range e.Zones() {
+			ss.WriteString(", " + z.Zone + " ")
+			if z.Asserted {
+				ss.WriteString("asserted")
+			} else {
+				ss.WriteString("clear")
+			}
+			ss.WriteString(" (" + strconv.Itoa(len(z.Relays)) + " relays)")
+		}
+		t.Message = ss.String()
+	default:
+		t.Error = true
+		t.Message = "error - " + EStopName + " does not understand Action: '" + string(t.Action) + "' (Assert [zone], Release [zone], Status)"
+	}
+	if t.ReportCh == nil {
+		println(t.Message)
+		return
+	}
+	t.ReportCh <- t
+}
@@ -0,0 +1,83 @@
+package core_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eyelight/relay/core"
+	"github.com/eyelight/relay/relaytest"
+)
+
+// staysOff fails t if out is driven on at any time over d
+func staysOff(t *testing.T, what string, out *relaytest.Output, d time.Duration) {
+	t.Helper()
+	for end := time.Now().Add(d); time.Now().Before(end); time.Sleep(time.Millisecond) {
+		if out.Get() {
+			t.Fatalf("%s: a TPO at 100%% switched the output on", what)
+		}
+	}
+}
+
+func TestLatchesHoldTPOOff(t *testing.T) {
+	out := relaytest.NewOutput()
+	r := core.New(out, "heater")
+	r.Configure()
+	r.SetShedPriority(1)
+	e := core.NewEStop(r)
+	tpo := core.NewTPO(r, 20*time.Millisecond)
+	tpo.SetOutput(100)
+	go tpo.Run()
+	defer tpo.Stop()
+	waitFor(t, "On", out.Get)
+
+	e.Assert("")
+	staysOff(t, "e-stopped", out, 100*time.Millisecond)
+	e.Release("")
+	waitFor(t, "On after release", out.Get)
+
+	core.Shed(1, r)
+	staysOff(t, "shed", out, 100*time.Millisecond)
+	core.Restore(0, r)
+	waitFor(t, "On after restore", out.Get)
+
+	r.Trip(core.FaultOvercurrent, "test")
+	staysOff(t, "faulted", out, 100*time.Millisecond)
+	if r.On() {
+		t.Error("On switched a faulted Relay on")
+	}
+	r.ClearFault()
+	waitFor(t, "On after clearing the fault", out.Get)
+}
+
+func TestEStopNotHeldUpByABusyRelay(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{})
+	socket := core.NewVirtual("socket",
+		func() error { close(started); <-release; return nil },
+		func() error { return nil })
+	socket.Configure()
+	out := relaytest.NewOutput()
+	pump := core.New(out, "pump")
+	pump.Configure()
+	e := core.NewEStop(socket, pump)
+	pump.Execute(core.Trigger{Target: "pump", Action: core.ActionOn, Source: core.SourceInternal})
+	waitFor(t, "pump On", out.Get)
+	go socket.Execute(core.Trigger{Target: "socket", Action: core.ActionOn, Source: core.SourceInternal})
+	<-started // the socket's worker is stuck in its On callback
+
+	asserted := make(chan struct{})
+	go func() {
+		e.Assert("")
+		close(asserted)
+	}()
+	select {
+	case <-asserted:
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("Assert waited on the busy socket")
+	}
+	if out.Get() {
+		t.Error("pump still on after Assert")
+	}
+	waitFor(t, "pump Off", func() bool { return !pump.Get() })
+}
@@ -0,0 +1,108 @@
+package core
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+// I2C is the part of an I2C bus an Expander needs; TinyGo's *machine.I2C satisfies it
+type I2C interface {
+	Tx(addr uint16, w, r []byte) error
+}
+
+// Expander is a PCF8574 8-bit I/O expander on an I2C bus, as fitted to many relay boards and
+// satellite relay modules. Each of its pins can drive a Relay through Output.
+type Expander struct {
+	bus      I2C
+	addr     uint16
+	mu       sync.Mutex // guards all below
+	port     uint8      // levels last written
+	err      error      // from the last transfer
+	lastSeen time.Time  // of the last successful transfer
+}
+
+// NewExpander returns an Expander at addr (0x20-0x27, or 0x38-0x3F for the PCF8574A) on bus,
+// with every pin high as the chip powers up
+func NewExpander(bus I2C, addr uint16) *Expander {
+	return &Expander{
+		bus:  bus,
+		addr: addr,
+		port: 0xFF,
+	}
+}
+
+// Output returns an Output driving pin n (0-7) of the Expander
+func (e *Expander) Output(n uint8) Output {
+	return expanderOutput{e: e, bit: 1 << (n & 7)}
+}
+
+// Ping reads the port to check the Expander answers
+func (e *Expander) Ping() error {
+	var b [1]byte
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.transferred(e.bus.Tx(e.addr, nil, b[:]))
+}
+
+// Err returns the error from the Expander's last transfer, or nil
+func (e *Expander) Err() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.err
+}
+
+// LastSeen returns when the Expander last answered, or the zero Time if it never has
+func (e *Expander) LastSeen() time.Time {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.lastSeen
+}
+
+// set writes the port with bit at level; e.mu must not be held
+func (e *Expander) set(bit uint8, level bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if level {
+		e.port |= bit
+	} else {
+		e.port &^= bit
+	}
+	e.transferred(e.bus.Tx(e.addr, []byte{e.port}, nil))
+}
+
+// transferred records the outcome of a transfer and returns err; e.mu must be held
+func (e *Expander) transferred(err error) error {
+	e.err = err
+	if err == nil {
+		e.lastSeen = now()
+	}
+	return err
+}
+
+// expanderOutput drives one pin of an Expander
+type expanderOutput struct {
+	e   *Expander
+	bit uint8
+}
+
+func (o expanderOutput) Configure() {}
+
+func (o expanderOutput) Set(level bool) {
+	o.e.set(o.bit, level)
+}
+
+// Get returns the level last written; the PCF8574's quasi-bidirectional pins read back the load, not the latch
+func (o expanderOutput) Get() bool {
+	o.e.mu.Lock()
+	defer o.e.mu.Unlock()
+	return o.e.port&o.bit != 0
+}
+
+func (o expanderOutput) String() string {
+	n := 0
+	for b := o.bit; b > 1; b >>= 1 {
+		n++
+	}
+	return "I2C 0x" + strconv.FormatUint(uint64(o.e.addr), 16) + " pin " + strconv.Itoa(n)
+}
@@ -0,0 +1,91 @@
+package core
+
+import (
+	"sync"
+	"time"
+)
+
+// fuseSampleInterval is how often a run samples its fuse's current sensor
+const fuseSampleInterval = 45 * time.Millisecond
+
+// expiry ends a timed run. It is driven by a timer rather than by polling, so a run switches off
+// within ±5ms of its duration – also for pulses well under a second – provided the scheduler isn't
+// starved by busy goroutines and the run's reports are read promptly. The timer runs on the Clock
+// set by SetClock, if any.
+type expiry struct {
+	ch    chan time.Time
+	mu    sync.Mutex // guards timer & gen
+	timer Timer      // nil for an indefinite run
+	gen   uint32     // advanced on every reset, so a superseded timer can't fire
+}
+
+// newExpiry returns an expiry firing after d, or never if d is not positive
+func newExpiry(d time.Duration) *expiry {
+	e := &expiry{ch: make(chan time.Time, 1)}
+	if d > 0 {
+		e.arm(d)
+	}
+	return e
+}
+
+// arm starts a timer firing after d, replacing any other
+func (e *expiry) arm(d time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.timer != nil {
+		e.timer.Stop()
+	}
+	e.gen++
+	gen := e.gen
+	select { // a fire already delivered by the replaced timer
+	case <-e.ch:
+	default:
+	}
+	e.timer = afterFunc(d, func() {
+		e.mu.Lock()
+		current := e.gen == gen
+		e.mu.Unlock()
+		if current {
+			select {
+			case e.ch <- now():
+			default:
+			}
+		}
+	})
+}
+
+// c returns the channel on which the expiry fires; nil (never ready) for an indefinite run
+func (e *expiry) c() <-chan time.Time {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.timer == nil {
+		return nil
+	}
+	return e.ch
+}
+
+// reset makes the expiry fire after d from now, immediately if d has already passed
+func (e *expiry) reset(d time.Duration) {
+	if d <= 0 {
+		d = 1
+	}
+	e.arm(d)
+}
+
+// stop releases the timer
+func (e *expiry) stop() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.timer != nil {
+		e.timer.Stop()
+	}
+	e.gen++
+}
+
+// clear makes the run indefinite; a later reset gives it an end again
+func (e *expiry) clear() {
+	e.stop()
+	e.mu.Lock()
+	e.timer = nil
+	e.mu.Unlock()
+}
@@ -0,0 +1,364 @@
+package core
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"time"
+)
+
+// A controller export is a compact binary record of every Relay's configuration, stats and history
+// and every Schedule, for backup over serial or the network and re-import onto a replacement board.
+// Integers are varints, strings and lists carry their length first, and times are Unix milliseconds.
+//
+//	export:   "RLYX" | version (1) | relay count | relays | schedule count | schedules
+//	relay:    name | config | stats | history count | entries
+//	schedule: name | relay name | at | days | action | duration | tags | avoid peak
+const exportVersion = 1
+
+// exportMagic begins every export
+var exportMagic = [4]byte{'R', 'L', 'Y', 'X'}
+
+// ErrBadExport is returned when an export can't be decoded
+var ErrBadExport = errors.New("relay: bad export")
+
+// Export records the configuration, stats and history of relays, and the Schedules of sc if it
+// isn't nil. A soft fuse's sensor, a Vote, a source policy and a Schedule's If can't be recorded,
+// so they must be set up again in code.
+func Export(sc *Scheduler, relays ...Relay) []byte {
+	e := encoder{b: append(append([]byte(nil), exportMagic[:]...), exportVersion)}
+	e.uint(uint64(len(relays)))
+	for _, r := range relays {
+		e.config(r.Config())
+		st := r.Stats()
+		e.uint(uint64(st.Cycles))
+		e.dur(st.OnTime)
+		e.uint(uint64(st.DayCycles))
+		e.dur(st.DayOnTime)
+		e.f32(st.Energy)
+		h := r.History()
+		e.uint(uint64(len(h)))
+		for _, en := range h {
+			e.uint(uint64(en.Seq))
+			e.time(en.Time)
+			e.b = append(e.b, byte(en.Kind), byte(en.Cause), byte(en.Source))
+			e.str(en.Detail)
+		}
+	}
+	var schedules []Schedule
+	if sc != nil {
+		sc.mu.Lock()
+		for _, s := range sc.schedules {
+			schedules = append(schedules, *s)
+		}
+		sc.mu.Unlock()
+	}
+	e.uint(uint64(len(schedules)))
+	for _, s := range schedules {
+		e.str(s.Name)
+		e.str(s.Relay.Name())
+		e.dur(s.At)
+		e.b = append(e.b, byte(s.Days))
+		e.str(string(s.Action))
+		e.dur(s.Duration)
+		e.strs(s.Tags)
+		e.bool(s.AvoidPeak)
+	}
+	return e.b
+}
+
+// Exported is the content of an export, as read by ReadExport
+type Exported struct {
+	Relays    []ExportedRelay
+	Schedules []ExportedSchedule
+}
+
+// ExportedRelay is one Relay's part of an export
+type ExportedRelay struct {
+	Config  RelayConfig
+	Stats   Stats
+	History []Entry
+}
+
+// ExportedSchedule is a Schedule as exported, its Relay named rather than bound
+type ExportedSchedule struct {
+	Schedule
+	RelayName string
+}
+
+// ReadExport decodes an export made by Export
+func ReadExport(b []byte) (Exported, error) {
+	var x Exported
+	if len(b) < 5 || [4]byte{b[0], b[1], b[2], b[3]} != exportMagic || b[4] != exportVersion {
+		return x, ErrBadExport
+	}
+	d := decoder{b: b[5:]}
+	for n := d.uint(); n > 0 && d.err == nil; n-- {
+		var xr ExportedRelay
+		xr.Config = d.config()
+		xr.Stats.Cycles = uint32(d.uint())
+		xr.Stats.OnTime = d.dur()
+		xr.Stats.DayCycles = uint32(d.uint())
+		xr.Stats.DayOnTime = d.dur()
+		xr.Stats.Energy = d.f32()
+		for m := d.uint(); m > 0 && d.err == nil; m-- {
+			en := Entry{Seq: uint32(d.uint()), Time: d.time()}
+			en.Kind, en.Cause, en.Source = EntryKind(d.byte()), Cause(d.byte()), Source(d.byte())
+			en.Detail = d.str()
+			xr.History = append(xr.History, en)
+		}
+		x.Relays = append(x.Relays, xr)
+	}
+	for n := d.uint(); n > 0 && d.err == nil; n-- {
+		var xs ExportedSchedule
+		xs.Name = d.str()
+		xs.RelayName = d.str()
+		xs.At = d.dur()
+		xs.Days = Weekdays(d.byte())
+		xs.Action = Action(d.str())
+		xs.Duration = d.dur()
+		xs.Tags = d.strs()
+		xs.AvoidPeak = d.bool()
+		x.Schedules = append(x.Schedules, xs)
+	}
+	if d.err == nil && len(d.b) > 0 {
+		d.err = ErrBadExport
+	}
+	return x, d.err
+}
+
+// Import applies an export made by Export to the Relays of a replacement board, matched by name:
+// their configuration and stats, and – if sc isn't nil – the Schedules of the Relays present.
+// Relays missing from relays are skipped. History is in the export for the record, but a Relay's
+// history starts afresh.
+func Import(b []byte, sc *Scheduler, relays ...Relay) error {
+	x, err := ReadExport(b)
+	if err != nil {
+		return err
+	}
+	byName := make(map[string]Relay, len(relays))
+	for _, r := range relays {
+		byName[r.Name()] = r
+	}
+	for _, xr := range x.Relays {
+		r, ok := byName[xr.Config.Name]
+		if !ok {
+			continue
+		}
+		apply(r, xr.Config)
+		if rr, ok := r.(*relay); ok {
+			rr.do(func() { rr.restoreStats(xr.Stats) })
+		}
+	}
+	if sc == nil {
+		return nil
+	}
+	for _, xs := range x.Schedules {
+		if r, ok := byName[xs.RelayName]; ok {
+			s := xs.Schedule
+			s.Relay = r
+			sc.Add(s)
+		}
+	}
+	return nil
+}
+
+// apply sets r up as c describes
+func apply(r Relay, c RelayConfig) {
+	r.SetActiveLow(c.ActiveLow)
+	r.SetNormallyClosed(c.NormallyClosed)
+	r.SetDefaultDuration(c.DefaultDuration)
+	r.SetMaxOn(c.MaxOn)
+	r.SetDutyBudget(c.DutyBudget)
+	r.AllowRemote(c.Remote...)
+	r.SetMinOn(c.MinOn, c.MinOnPolicy)
+	for k, v := range c.Tags {
+		r.SetTag(k, v)
+	}
+	r.SetShedPriority(c.ShedPriority)
+	r.SetReconcile(c.Reconcile)
+	r.SetRetrigger(c.Retrigger)
+	r.SetLoadPower(c.LoadPower)
+	r.SetRollover(c.Rollover)
+	r.SetLocalOverride(c.LocalOverride)
+	r.SetDiagnostics(c.Diagnostics)
+	r.SetRepulse(c.Repulse)
+	r.SetQuiet(c.Quiet)
+	r.SetEStopZones(c.EStopZones...)
+	r.SetPeakPolicy(c.PeakPolicy)
+}
+
+// restoreStats carries exported counters over to the Relay; it is only ever called from the worker
+func (r *relay) restoreStats(st Stats) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cur := r.stats()
+	r.cycles = st.Cycles
+	r.onTotal += st.OnTime - cur.OnTime
+	r.dayCycles = st.Cycles - st.DayCycles
+	r.dayOnTime = r.onTotal - st.DayOnTime
+	r.energyOnTime = r.onTotal
+	if r.watts > 0 {
+		r.energyOnTime -= time.Duration(float64(st.Energy/r.watts) * float64(time.Hour))
+	}
+}
+
+// config records the settings of c that can be restored
+func (e *encoder) config(c RelayConfig) {
+	e.str(c.Name)
+	e.str(c.Output)
+	for _, b := range []bool{c.ActiveLow, c.NormallyClosed, c.Retrigger, c.Rollover} {
+		e.bool(b)
+	}
+	for _, d := range []time.Duration{c.DefaultDuration, c.MaxOn, c.DutyBudget, c.MinOn, c.Reconcile, c.LocalOverride, c.Diagnostics, c.Repulse, c.Quiet.From, c.Quiet.To} {
+		e.dur(d)
+	}
+	e.b = append(e.b, byte(c.MinOnPolicy), c.ShedPriority, byte(c.Quiet.Policy), byte(c.Footprint), byte(c.PeakPolicy))
+	e.f32(c.LoadPower)
+	e.strs(c.Remote)
+	e.uint(uint64(len(c.Tags)))
+	for k, v := range c.Tags {
+		e.str(k)
+		e.str(v)
+	}
+	e.strs(c.EStopZones)
+}
+
+// config reads settings recorded by encoder.config
+func (d *decoder) config() RelayConfig {
+	var c RelayConfig
+	c.Name = d.str()
+	c.Output = d.str()
+	for _, b := range []*bool{&c.ActiveLow, &c.NormallyClosed, &c.Retrigger, &c.Rollover} {
+		*b = d.bool()
+	}
+	for _, p := range []*time.Duration{&c.DefaultDuration, &c.MaxOn, &c.DutyBudget, &c.MinOn, &c.Reconcile, &c.LocalOverride, &c.Diagnostics, &c.Repulse, &c.Quiet.From, &c.Quiet.To} {
+		*p = d.dur()
+	}
+	c.MinOnPolicy = MinOnPolicy(d.byte())
+	c.ShedPriority = d.byte()
+	c.Quiet.Policy = QuietPolicy(d.byte())
+	c.Footprint = Footprint(d.byte())
+	c.PeakPolicy = PeakPolicy(d.byte())
+	c.LoadPower = d.f32()
+	c.Remote = d.strs()
+	if n := d.uint(); n > 0 && d.err == nil {
+		c.Tags = make(map[string]string, n)
+		for ; n > 0 && d.err == nil; n-- {
+			k := d.str()
+			c.Tags[k] = d.str()
+		}
+	}
+	c.EStopZones = d.strs()
+	return c
+}
+
+// encoder appends the fields of an export
+type encoder struct {
+	b []byte
+}
+
+func (e *encoder) uint(v uint64) {
+	var buf [binary.MaxVarintLen64]byte
+	e.b = append(e.b, buf[:binary.PutUvarint(buf[:], v)]...)
+}
+
+func (e *encoder) dur(d time.Duration) {
+	var buf [binary.MaxVarintLen64]byte
+	e.b = append(e.b, buf[:binary.PutVarint(buf[:], int64(d))]...)
+}
+
+func (e *encoder) time(t time.Time) {
+	e.dur(time.Duration(t.UnixMilli()))
+}
+
+func (e *encoder) f32(f float32) {
+	e.uint(uint64(math.Float32bits(f)))
+}
+
+func (e *encoder) bool(v bool) {
+	if v {
+		e.b = append(e.b, 1)
+	} else {
+		e.b = append(e.b, 0)
+	}
+}
+
+func (e *encoder) str(s string) {
+	e.uint(uint64(len(s)))
+	e.b = append(e.b, s...)
+}
+
+func (e *encoder) strs(ss []string) {
+	e.uint(uint64(len(ss)))
+	for _, s := range ss {
+		e.str(s)
+	}
+}
+
+// decoder reads the fields of an export; after the first error, every read returns a zero value
+type decoder struct {
+	b   []byte
+	err error
+}
+
+func (d *decoder) uint() uint64 {
+	v, n := binary.Uvarint(d.b)
+	if d.err != nil || n <= 0 {
+		d.err = ErrBadExport
+		return 0
+	}
+	d.b = d.b[n:]
+	return v
+}
+
+func (d *decoder) dur() time.Duration {
+	v, n := binary.Varint(d.b)
+	if d.err != nil || n <= 0 {
+		d.err = ErrBadExport
+		return 0
+	}
+	d.b = d.b[n:]
+	return time.Duration(v)
+}
+
+func (d *decoder) time() time.Time {
+	return time.UnixMilli(int64(d.dur()))
+}
+
+func (d *decoder) f32() float32 {
+	return math.Float32frombits(uint32(d.uint()))
+}
+
+func (d *decoder) byte() byte {
+	if d.err != nil || len(d.b) == 0 {
+		d.err = ErrBadExport
+		return 0
+	}
+	v := d.b[0]
+	d.b = d.b[1:]
+	return v
+}
+
+func (d *decoder) bool() bool {
+	return d.byte() != 0
+}
+
+func (d *decoder) str() string {
+	n := d.uint()
+	if d.err != nil || uint64(len(d.b)) < n {
+		d.err = ErrBadExport
+		return ""
+	}
+	s := string(d.b[:n])
+	d.b = d.b[n:]
+	return s
+}
+
+func (d *decoder) strs() []string {
+	var ss []string
+	for n := d.uint(); n > 0 && d.err == nil; n-- {
+		ss = append(ss, d.str())
+	}
+	return ss
+}
@@ -0,0 +1,38 @@
+package core
+
+// armElse cancels any fallback pending from an earlier command and, if a carries one, arms it: unless
+// another "On" or "Off" is accepted within a.ElseAfter, the Relay takes a.Else. A refused command
+// leaves the pending fallback as it was. It generalizes a heartbeat
+// to single commands over unreliable links, e.g. "On 10m else Off after 30s" sent every 20s.
+func (r *relay) armElse(t Trigger, a ParsedAction) {
+	next := Trigger{Target: r.Name(), Action: a.Else, ReportCh: t.ReportCh, Source: SourceInternal}
+	if a.Else == ActionPrevious {
+		on := r.recordedOn()
+		left, _ := r.Remaining()
+		if left > 0 { // what would by then be left of the previous run
+			left -= a.ElseAfter
+			on = left > 0
+		}
+		next = restore(r, on, left, t.ReportCh)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.pendingElse != nil {
+		r.pendingElse.Stop()
+		r.pendingElse = nil
+	}
+	if a.Else == "" {
+		return
+	}
+	var timer Timer
+	timer = afterFunc(a.ElseAfter, func() {
+		r.mu.Lock()
+		armed := r.pendingElse == timer
+		r.mu.Unlock()
+		if armed {
+			println("relay " + r.Name() + ": nothing since '" + string(t.Action) + "', falling back to " + string(next.Action))
+			r.Execute(next)
+		}
+	})
+	r.pendingElse = timer
+}
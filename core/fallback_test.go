@@ -0,0 +1,25 @@
+package core_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/eyelight/relay/core"
+	"github.com/eyelight/relay/relaytest"
+)
+
+func TestRefusedCommandLeavesFallback(t *testing.T) {
+	r := core.New(relaytest.NewOutput(), "pump")
+	r.Configure()
+	var confirm int32 = 1
+	r.SetVote(core.NewVote(1, func() bool { return atomic.LoadInt32(&confirm) == 1 }))
+	r.Execute(core.Trigger{Target: "pump", Action: "On 1h else Off after 100ms", Source: core.SourceRemote})
+	waitFor(t, "On", r.Get)
+
+	atomic.StoreInt32(&confirm, 0)
+	rec := relaytest.NewRecorder()
+	r.Execute(core.Trigger{Target: "pump", Action: "On 1h", Source: core.SourceRemote, ReportCh: rec.C()})
+	rec.Expect(t, "inputs confirm", time.Second)
+	waitFor(t, "the fallback Off", func() bool { return !r.Get() })
+}
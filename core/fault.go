@@ -0,0 +1,79 @@
+package core
+
+// Fault describes why a Relay has been taken out of service. A faulted Relay refuses
+// "On" Triggers until the Fault is cleared.
+type Fault uint8
+
+const (
+	NoFault Fault = iota
+	FaultOvercurrent
+	FaultOpenLoad // the driver sees no load: a broken wire or blown lamp
+	FaultOvertemp // the driver shut down hot
+	FaultShort    // the driver sees a short circuit
+	FaultFeedback // the load's state doesn't match what the relay was told, e.g. a failed contact
+)
+
+// String returns the Fault's name for use in reports
+func (f Fault) String() string {
+	switch f {
+	case NoFault:
+		return "none"
+	case FaultOvercurrent:
+		return "overcurrent"
+	case FaultOpenLoad:
+		return "open load"
+	case FaultOvertemp:
+		return "overtemperature"
+	case FaultShort:
+		return "short circuit"
+	case FaultFeedback:
+		return "feedback mismatch"
+	default:
+		return "unknown"
+	}
+}
+
+// BlinkCode returns the number of blinks an Indicator shows for the Fault: 2 for an overcurrent,
+// 3 for a feedback mismatch, 4 for an open load, 5 for overtemperature and 6 for a short circuit.
+// The codes are what technicians read off a board, so they're fixed whatever order the Faults are
+// declared in. NoFault gives 0, and a Fault this version doesn't know gives 1.
+func (f Fault) BlinkCode() int {
+	switch f {
+	case NoFault:
+		return 0
+	case FaultOvercurrent:
+		return 2
+	case FaultFeedback:
+		return 3
+	case FaultOpenLoad:
+		return 4
+	case FaultOvertemp:
+		return 5
+	case FaultShort:
+		return 6
+	default:
+		return 1
+	}
+}
+
+// Fault returns the Relay's latched Fault, or NoFault
+func (r *relay) Fault() Fault {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.fault
+}
+
+// ClearFault returns a faulted Relay to service, marking the Fault cleared in its fault history
+func (r *relay) ClearFault() {
+	r.mu.Lock()
+	if r.fault == NoFault {
+		r.mu.Unlock()
+		return
+	}
+	r.fault = NoFault
+	if n := len(r.faultLog); n > 0 {
+		r.faultLog[n-1].Cleared = now()
+	}
+	r.mu.Unlock()
+	r.saveFaults()
+}
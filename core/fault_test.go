@@ -0,0 +1,26 @@
+package core_test
+
+import (
+	"testing"
+
+	"github.com/eyelight/relay/core"
+)
+
+func TestBlinkCode(t *testing.T) {
+	for _, c := range []struct {
+		f    core.Fault
+		want int
+	}{
+		{core.NoFault, 0},
+		{core.FaultOvercurrent, 2},
+		{core.FaultFeedback, 3},
+		{core.FaultOpenLoad, 4},
+		{core.FaultOvertemp, 5},
+		{core.FaultShort, 6},
+		{core.FaultFeedback + 1, 1},
+	} {
+		if got := c.f.BlinkCode(); got != c.want {
+			t.Errorf("%v.BlinkCode() = %d, want %d", c.f, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package core
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+// ErrBadFaultLog is returned when a stored fault history can't be decoded
+var ErrBadFaultLog = errors.New("relay: bad fault log")
+
+// FaultRecord is one latched Fault in a Relay's fault history
+type FaultRecord struct {
+	Fault   Fault
+	Time    time.Time // when it latched
+	Detail  string    // e.g. the fuse's reading
+	Cleared time.Time // when ClearFault returned the Relay to service; zero while it is latched
+}
+
+// FaultHistory returns the Relay's latest latched Faults, oldest first, including any from before a reboot
+func (r *relay) FaultHistory() []FaultRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]FaultRecord(nil), r.faultLog...)
+}
+
+// SetFaultStore keeps the Relay's fault history in s, so a Fault latched before a power cycle stays
+// latched after it, and the Relay refuses "On" until ClearFault. Call it after the Relay has been
+// configured; a Fault restored from s is reported to the report fallback.
+func (r *relay) SetFaultStore(s Store) error {
+	b, err := s.Load(faultKey(r.Name()))
+	if err != nil {
+		return err
+	}
+	log, err := decodeFaults(b)
+	if err != nil {
+		return err
+	}
+	if max := r.footprint.Sizes().FaultLog; len(log) > max {
+		log = log[len(log)-max:]
+	}
+	r.mu.Lock()
+	r.faultStore = s
+	r.faultLog = log
+	r.mu.Unlock()
+	if n := len(log); n > 0 && log[n-1].Cleared.IsZero() {
+		rec := log[n-1]
+		r.do(func() {
+			r.mu.Lock()
+			r.fault = rec.Fault
+			r.mu.Unlock()
+			r.forceOff(CauseDiagnostic)
+		})
+		r.send(Trigger{Target: r.Name(), Action: "Restore"}, Report{Kind: ReportFault, Error: true, Fault: rec.Fault,
+			Detail: rec.Detail + ", latched since " + stamp(rec.Time)})
+	}
+	return nil
+}
+
+// latch puts the Relay in Fault f and records it in the fault history
+func (r *relay) latch(f Fault, detail string) {
+	r.mu.Lock()
+	r.fault = f
+	r.faultLog = append(r.faultLog, FaultRecord{Fault: f, Time: now(), Detail: detail})
+	if max := r.footprint.Sizes().FaultLog; len(r.faultLog) > max {
+		r.faultLog = r.faultLog[len(r.faultLog)-max:]
+	}
+	r.mu.Unlock()
+	r.saveFaults()
+}
+
+// saveFaults writes the fault history to the fault Store, if there is one
+func (r *relay) saveFaults() {
+	r.mu.Lock()
+	s, b := r.faultStore, encodeFaults(r.faultLog)
+	r.mu.Unlock()
+	if s == nil {
+		return
+	}
+	if err := s.Save(faultKey(r.Name()), b); err != nil {
+		println("relay " + r.Name() + ": could not save its fault history: " + err.Error())
+	}
+}
+
+// encodeFaults packs a fault history: for each record its Fault, the Unix milliseconds at which it
+// latched and was cleared (0 while latched), and its Detail, preceded by the Detail's length
+func encodeFaults(log []FaultRecord) []byte {
+	var b []byte
+	for _, rec := range log {
+		detail := rec.Detail
+		if len(detail) > 255 {
+			detail = detail[:255]
+		}
+		var cleared int64
+		if !rec.Cleared.IsZero() {
+			cleared = rec.Cleared.UnixMilli()
+		}
+		var head [18]byte
+		head[0] = byte(rec.Fault)
+		binary.LittleEndian.PutUint64(head[1:], uint64(rec.Time.UnixMilli()))
+		binary.LittleEndian.PutUint64(head[9:], uint64(cleared))
+		head[17] = byte(len(detail))
+		b = append(b, head[:]...)
+		b = append(b, detail...)
+	}
+	return b
+}
+
+// decodeFaults unpacks a fault history packed by encodeFaults
+func decodeFaults(b []byte) ([]FaultRecord, error) {
+	var log []FaultRecord
+	for len(b) > 0 {
+		if len(b) < 18 || len(b) < 18+int(b[17]) {
+			return nil, ErrBadFaultLog
+		}
+		rec := FaultRecord{
+			Fault:  Fault(b[0]),
+			Time:   time.UnixMilli(int64(binary.LittleEndian.Uint64(b[1:]))),
+			Detail: string(b[18 : 18+int(b[17])]),
+		}
+		if ms := int64(binary.LittleEndian.Uint64(b[9:])); ms != 0 {
+			rec.Cleared = time.UnixMilli(ms)
+		}
+		log = append(log, rec)
+		b = b[18+int(b[17]):]
+	}
+	return log, nil
+}
+
+// faultKey is the Store key under which the fault history of the Relay called name is kept
+func faultKey(name string) string {
+	return "relay/" + name + "/faults"
+}
@@ -0,0 +1,69 @@
+package core
+
+import (
+	"sync/atomic"
+	"unsafe"
+)
+
+// Footprint trades a Relay's features for memory: how much history it keeps, how many Triggers may
+// queue for it and how many faults it remembers. An ATSAMD21 can run four Relays in a few KB at
+// FootprintMinimal, while an ESP32 can afford FootprintFull.
+type Footprint uint8
+
+const (
+	FootprintStandard Footprint = iota // the default
+	FootprintMinimal
+	FootprintFull
+)
+
+// Sizes are the retention and queue depths a Footprint sets
+type Sizes struct {
+	History  int // Entries kept for History and report sequencing
+	Queue    int // Triggers that may wait for the worker before more are refused as busy
+	FaultLog int // FaultRecords kept by FaultHistory and the fault Store
+}
+
+// footprint is the Footprint given to new Relays; atomic
+var footprint uint32
+
+// SetFootprint sets the Footprint of the Relays created from then on; call it before New
+func SetFootprint(f Footprint) {
+	atomic.StoreUint32(&footprint, uint32(f))
+}
+
+// String returns the Footprint's name for use in reports
+func (f Footprint) String() string {
+	switch f {
+	case FootprintMinimal:
+		return "minimal"
+	case FootprintStandard:
+		return "standard"
+	case FootprintFull:
+		return "full"
+	default:
+		return "unknown"
+	}
+}
+
+// Sizes returns the retention and queue depths of the Footprint
+func (f Footprint) Sizes() Sizes {
+	switch f {
+	case FootprintMinimal:
+		return Sizes{History: 4, Queue: 1, FaultLog: 2}
+	case FootprintFull:
+		return Sizes{History: 64, Queue: 16, FaultLog: 32}
+	default:
+		return Sizes{History: 16, Queue: 4, FaultLog: 8}
+	}
+}
+
+// Bytes estimates the heap a Relay takes at the Footprint: the Relay itself, its history, queues and
+// fault log, but neither its worker's goroutine stack – fixed by the target, often 2–4KB – nor the
+// text of fault details, tags and the optional features set on it
+func (f Footprint) Bytes() int {
+	s := f.Sizes()
+	return int(unsafe.Sizeof(relay{})) +
+		s.History*int(unsafe.Sizeof(Entry{})) +
+		(s.Queue+1)*int(unsafe.Sizeof(command{})) +
+		s.FaultLog*int(unsafe.Sizeof(FaultRecord{}))
+}
@@ -0,0 +1,71 @@
+package core
+
+import (
+	"strconv"
+	"time"
+)
+
+// CurrentSensor reports the current drawn through a Relay's load in amps,
+// e.g. an ACS712 read through the ADC or an INA219 read over I2C
+type CurrentSensor interface {
+	Current() float32
+}
+
+// fuse holds the settings & bookkeeping of a Relay's soft fuse
+type fuse struct {
+	sensor    CurrentSensor
+	limit     float32       // amps
+	grace     time.Duration // how long the limit may be exceeded before tripping
+	overSince time.Time     // when the limit was first exceeded; zero while under it
+	last      float32       // the most recent reading
+}
+
+// SetFuse samples s during runs and force-opens the Relay with an overcurrent Fault if the
+// reading stays above limit amps for longer than grace. Passing a nil sensor removes the fuse.
+func (r *relay) SetFuse(s CurrentSensor, limit float32, grace time.Duration) {
+	var f *fuse
+	if s != nil {
+		f = &fuse{
+			sensor: s,
+			limit:  limit,
+			grace:  grace,
+		}
+	}
+	r.mu.Lock()
+	r.fuse = f
+	r.mu.Unlock()
+}
+
+// currentFuse returns the Relay's fuse, or nil; a run samples the one fitted when it started
+func (r *relay) currentFuse() *fuse {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.fuse
+}
+
+// fuseTripped samples f's current sensor and reports whether the fuse has blown, latching the Fault if so
+func (r *relay) fuseTripped(f *fuse) bool {
+	if f == nil {
+		return false
+	}
+	f.last = f.sensor.Current()
+	if f.last <= f.limit {
+		f.overSince = time.Time{}
+		return false
+	}
+	if f.overSince.IsZero() {
+		f.overSince = now()
+	}
+	if since(f.overSince) < f.grace {
+		return false
+	}
+	f.overSince = time.Time{}
+	r.latch(FaultOvercurrent, f.message())
+	return true
+}
+
+// message describes a blown fuse for reports
+func (f *fuse) message() string {
+	return strconv.FormatFloat(float64(f.last), 'f', 2, 32) + "A exceeded the " +
+		strconv.FormatFloat(float64(f.limit), 'f', 2, 32) + "A limit for longer than " + f.grace.String()
+}
@@ -0,0 +1,100 @@
+package core
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+// Store is a persistence backend (flash, EEPROM, a file) that survives a reset
+type Store interface {
+	Save(key string, data []byte) error
+	Load(key string) ([]byte, error)
+}
+
+// ErrBadSnapshot is returned when a stored snapshot can't be decoded
+var ErrBadSnapshot = errors.New("relay: bad snapshot")
+
+const (
+	snapshotLen        = 9 // flags byte + remaining milliseconds
+	snapshotRunning    = 1 << 0
+	snapshotIndefinite = 1 << 1
+)
+
+// Remaining returns how much of the Relay's current run is left and whether a run is in progress.
+// An indefinite run has 0 remaining.
+func (r *relay) Remaining() (time.Duration, bool) {
+	if r.current() == nil {
+		return 0, false
+	}
+	r.mu.Lock()
+	onTime, d := r.onTime, r.duration
+	r.mu.Unlock()
+	if d <= 0 {
+		return 0, true
+	}
+	left := d - since(onTime)
+	if left < time.Millisecond {
+		left = time.Millisecond
+	}
+	return left, true
+}
+
+// Snapshot saves each Relay's in-flight run to s, to be picked up by Resume after a
+// software-initiated reset such as an OTA update. Call it just before resetting.
+func Snapshot(s Store, relays ...Relay) error {
+	for _, r := range relays {
+		var b [snapshotLen]byte
+		if left, ok := r.Remaining(); ok {
+			b[0] = snapshotRunning
+			if left == 0 {
+				b[0] |= snapshotIndefinite
+			}
+			binary.LittleEndian.PutUint64(b[1:], uint64(left/time.Millisecond))
+		}
+		if err := s.Save(snapshotKey(r), b[:]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Resume restarts the runs saved by Snapshot with their remaining durations, reporting on reportCh,
+// and clears the snapshots so a later ordinary boot doesn't resume them again. Time spent
+// rebooting is not deducted. Call it after the Relays have been configured.
+func Resume(s Store, reportCh chan Trigger, relays ...Relay) error {
+	for _, r := range relays {
+		b, err := s.Load(snapshotKey(r))
+		if err != nil {
+			return err
+		}
+		if len(b) == 0 {
+			continue
+		}
+		if len(b) != snapshotLen {
+			return ErrBadSnapshot
+		}
+		if err := s.Save(snapshotKey(r), nil); err != nil {
+			return err
+		}
+		if b[0]&snapshotRunning == 0 {
+			continue
+		}
+		t := Trigger{
+			Target:   r.Name(),
+			Action:   ActionOn,
+			ReportCh: reportCh,
+			Source:   SourceInternal,
+		}
+		if b[0]&snapshotIndefinite == 0 {
+			t.Duration = time.Duration(binary.LittleEndian.Uint64(b[1:])) * time.Millisecond
+		}
+		r.Execute(t)
+	}
+	return nil
+}
+
+// snapshotKey is the Store key under which a Relay's snapshot is kept
+func snapshotKey(r Relay) string {
+	return "relay/" + r.Name() + "/run"
+}
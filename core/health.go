@@ -0,0 +1,62 @@
+package core
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// healthProbe is how long Health waits for the worker to answer before declaring it unresponsive
+const healthProbe = 100 * time.Millisecond
+
+// Health describes the state of a Relay's machinery, for a supervisor task watching for wedged Relays
+type Health struct {
+	Responsive     bool      // the worker answered a probe within healthProbe
+	LastTick       time.Time // when the worker last took a Trigger or control call; zero if never
+	Queued         int       // Triggers waiting for the worker
+	OffPending     bool      // an "Off" is waiting for the worker
+	Running        bool      // a run is in progress
+	DroppedOffs    uint32    // "Off" Triggers dropped because one was already waiting
+	DroppedReports uint32    // reports lost to a closed ReportCh, or busy refusals with no room on theirs
+	Busy           uint32    // Triggers refused because the queue was full
+	DropOuts       uint32    // times the Output was found dropped out while on; see SetRepulse
+	Fault          Fault
+	Shed           bool
+}
+
+// Health probes the Relay's worker and gathers its queue depths, drop counters and fault flags.
+// It returns within healthProbe even if the worker is stuck.
+func (r *relay) Health() Health {
+	h := Health{
+		Queued:         len(r.cmd),
+		OffPending:     len(r.offCh) > 0,
+		Running:        r.current() != nil,
+		DroppedOffs:    atomic.LoadUint32(&r.droppedOffs),
+		DroppedReports: atomic.LoadUint32(&r.droppedReports),
+		Busy:           atomic.LoadUint32(&r.busy),
+		DropOuts:       atomic.LoadUint32(&r.dropOuts),
+		Fault:          r.Fault(),
+	}
+	probe := time.NewTimer(healthProbe)
+	defer probe.Stop()
+	shed := make(chan bool, 1)
+	select {
+	case r.ctl <- func() { shed <- r.Shed() }:
+		select {
+		case h.Shed = <-shed:
+			h.Responsive = true
+		case <-probe.C:
+		}
+	case <-probe.C:
+	}
+	r.mu.Lock()
+	h.LastTick = r.lastTick
+	r.mu.Unlock()
+	return h
+}
+
+// tick records that the worker is alive
+func (r *relay) tick() {
+	r.mu.Lock()
+	r.lastTick = now()
+	r.mu.Unlock()
+}
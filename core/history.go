@@ -0,0 +1,67 @@
+package core
+
+import "time"
+
+// EntryKind says whether a history Entry records an accepted command or a transition
+type EntryKind uint8
+
+const (
+	EntryCommand EntryKind = iota
+	EntryTransition
+)
+
+// Entry is one line of a Relay's audit trail. Seq increases by one for every accepted command and
+// every transition, so a gap in the sequence numbers seen downstream means a lost message.
+type Entry struct {
+	Seq    uint32
+	Time   time.Time
+	Kind   EntryKind
+	Cause  Cause
+	Source Source // for command Entries and the transitions they cause, where the command came from
+	Detail string // e.g. "On 30m0s" for a command, "Off" for a transition
+}
+
+// History returns the Relay's most recent Entries, as many as its Footprint keeps, oldest first
+func (r *relay) History() []Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := int(r.seq)
+	if n > len(r.history) {
+		n = len(r.history)
+	}
+	h := make([]Entry, 0, n)
+	for i := int(r.seq) - n; i < int(r.seq); i++ {
+		h = append(h, r.history[i%len(r.history)])
+	}
+	return h
+}
+
+// Seq returns the sequence number of the Relay's latest Entry; 0 means nothing has happened yet
+func (r *relay) Seq() uint32 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.seq
+}
+
+// audit numbers an accepted command or transition and records it in the history
+func (r *relay) audit(kind EntryKind, detail string, c Cause) uint32 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if kind == EntryCommand {
+		r.commands.Accepted++
+	}
+	src := SourceInternal
+	if c == CauseCommand || c == CauseSchedule {
+		src = r.source
+	}
+	r.history[r.seq%uint32(len(r.history))] = Entry{
+		Seq:    r.seq + 1,
+		Time:   now(),
+		Kind:   kind,
+		Cause:  c,
+		Source: src,
+		Detail: detail,
+	}
+	r.seq++
+	return r.seq
+}
@@ -0,0 +1,94 @@
+package core
+
+import (
+	"strconv"
+	"time"
+)
+
+// Humidistat drives a dehumidifier or extractor fan Relay from relative humidity readings
+type Humidistat struct {
+	relay      Relay
+	readings   <-chan float32
+	setpoint   float32
+	deadband   float32
+	minOn      time.Duration
+	minOff     time.Duration
+	lastSwitch time.Time
+	reportCh   chan Trigger
+}
+
+// NewHumidistat returns a Humidistat holding humidity (%RH) around setpoint: the Relay turns on above
+// setpoint+deadband/2 and off below setpoint-deadband/2, but never before it has been on for minOn
+// or off for minOff. Each control decision is reported on reportCh, which may be nil.
+func NewHumidistat(r Relay, readings <-chan float32, setpoint, deadband float32, minOn, minOff time.Duration, reportCh chan Trigger) *Humidistat {
+	return &Humidistat{
+		relay:    r,
+		readings: readings,
+		setpoint: setpoint,
+		deadband: deadband,
+		minOn:    minOn,
+		minOff:   minOff,
+		reportCh: reportCh,
+	}
+}
+
+// Run acts on each reading until the readings channel is closed
+func (h *Humidistat) Run() {
+	for rh := range h.readings {
+		h.update(rh)
+	}
+}
+
+// update decides whether the reading calls for a change and makes it if the minimum times allow
+func (h *Humidistat) update(rh float32) {
+	on := h.relay.Get()
+	var want bool
+	switch {
+	case !on && rh > h.setpoint+h.deadband/2:
+		want = true
+	case on && rh < h.setpoint-h.deadband/2:
+		want = false
+	default:
+		return
+	}
+
+	reading := strconv.FormatFloat(float64(rh), 'f', 1, 32) + "%RH"
+	dwelt := since(h.lastSwitch)
+	if !h.lastSwitch.IsZero() {
+		if want && dwelt < h.minOff {
+			h.report(ActionOn, h.relay.Name()+" - Humidistat holding Off at "+reading+", minimum off time "+h.minOff.String()+" not reached", false)
+			return
+		}
+		if !want && dwelt < h.minOn {
+			h.report(ActionOff, h.relay.Name()+" - Humidistat holding On at "+reading+", minimum on time "+h.minOn.String()+" not reached", false)
+			return
+		}
+	}
+
+	action, ok := ActionOn, false
+	if want {
+		ok = h.relay.On()
+	} else {
+		action = ActionOff
+		ok = !h.relay.Off()
+	}
+	h.lastSwitch = now()
+	if !ok {
+		h.report(action, "error - "+h.relay.Name()+" - Humidistat could not switch "+string(action)+" at "+reading, true)
+		return
+	}
+	h.report(action, h.relay.Name()+" - Humidistat switched "+string(action)+" at "+reading+" (setpoint "+strconv.FormatFloat(float64(h.setpoint), 'f', 1, 32)+"%RH)", false)
+}
+
+// report sends a status Trigger describing a control decision
+func (h *Humidistat) report(action Action, msg string, isErr bool) {
+	if h.reportCh == nil {
+		return
+	}
+	h.reportCh <- Trigger{
+		Target:  h.relay.Name(),
+		Action:  action,
+		Message: msg,
+		Error:   isErr,
+	}
+}
@@ -0,0 +1,74 @@
+package core
+
+import "time"
+
+const (
+	blinkOn       = 200 * time.Millisecond
+	blinkOff      = 300 * time.Millisecond
+	blinkPause    = 1500 * time.Millisecond // between repeats of a blink code
+	indicatorPoll = 100 * time.Millisecond
+)
+
+// Indicator drives a status LED for a Relay: lit while the load is on, and while the Relay is in
+// Fault, blinking the Fault's BlinkCode over and over with a pause between, so a technician can
+// read the fault off the board without a laptop.
+type Indicator struct {
+	relay Relay
+	led   Output
+	stop  chan struct{}
+}
+
+// NewIndicator returns an Indicator showing r's state on led, which it configures
+func NewIndicator(r Relay, led Output) *Indicator {
+	led.Configure()
+	return &Indicator{
+		relay: r,
+		led:   led,
+		stop:  make(chan struct{}),
+	}
+}
+
+// Run drives the LED until Stop is called, leaving it dark
+func (in *Indicator) Run() {
+	defer in.led.Set(false)
+	for {
+		code := in.relay.Fault().BlinkCode()
+		if code == 0 {
+			in.led.Set(in.relay.Load())
+			if !in.wait(indicatorPoll) {
+				return
+			}
+			continue
+		}
+		for i := 0; i < code; i++ {
+			in.led.Set(true)
+			if !in.wait(blinkOn) {
+				return
+			}
+			in.led.Set(false)
+			if !in.wait(blinkOff) {
+				return
+			}
+		}
+		if !in.wait(blinkPause) {
+			return
+		}
+	}
+}
+
+// Stop ends Run
+func (in *Indicator) Stop() {
+	close(in.stop)
+}
+
+// wait sleeps for d, returning false if the Indicator was stopped meanwhile
+func (in *Indicator) wait(d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-in.stop:
+		return false
+	}
+}
@@ -0,0 +1,190 @@
+package core
+
+import (
+	"strconv"
+	"sync"
+)
+
+// InterlockPolicy says what happens to an "On" Trigger that would exceed an Interlock's limit
+type InterlockPolicy uint8
+
+const (
+	InterlockRefuse InterlockPolicy = iota // refuse the Trigger
+	InterlockQueue                         // hold the Trigger until a member switches off
+)
+
+// Interlock allows at most max of its member Relays to be on at once, e.g. only 2 of 6 heaters
+// to stay under a breaker's rating; max 1 gives mutual exclusion. It governs "On" Triggers;
+// the low-level On and Set methods bypass it.
+type Interlock struct {
+	mu       sync.Mutex
+	max      int
+	policy   InterlockPolicy
+	on       map[*relay]bool
+	reserved map[*relay]bool // admitted but not yet switched on
+	queue    []queued
+}
+
+// queued is an "On" Trigger waiting for room in an Interlock
+type queued struct {
+	r *relay
+	t Trigger
+}
+
+// NewInterlock returns an Interlock over relays allowing at most max of them on at once
+func NewInterlock(max int, policy InterlockPolicy, relays ...Relay) *Interlock {
+	il := &Interlock{
+		max:      max,
+		policy:   policy,
+		on:       make(map[*relay]bool, len(relays)),
+		reserved: make(map[*relay]bool, max),
+	}
+	for _, rr := range relays {
+		r, ok := rr.(*relay)
+		if !ok {
+			continue
+		}
+		il.on[r] = r.Get()
+		r.mu.Lock()
+		r.interlocks = append(r.interlocks, il)
+		r.mu.Unlock()
+		r.Watch(func(on bool) { il.transition(r, on) })
+	}
+	return il
+}
+
+// Count returns how many members are on or about to be
+func (il *Interlock) Count() int {
+	il.mu.Lock()
+	defer il.mu.Unlock()
+	return il.count()
+}
+
+// count must be called with il.mu held
+func (il *Interlock) count() int {
+	n := len(il.reserved)
+	for r, on := range il.on {
+		if on && !il.reserved[r] {
+			n++
+		}
+	}
+	return n
+}
+
+// admit reserves room for r to switch on, returning false if the Interlock is full
+func (il *Interlock) admit(r *relay) bool {
+	il.mu.Lock()
+	defer il.mu.Unlock()
+	if il.on[r] || il.reserved[r] {
+		return true
+	}
+	if il.count() >= il.max {
+		return false
+	}
+	il.reserved[r] = true
+	return true
+}
+
+// wouldAdmit reports whether r could switch on now, without reserving room
+func (il *Interlock) wouldAdmit(r *relay) bool {
+	il.mu.Lock()
+	defer il.mu.Unlock()
+	return il.on[r] || il.reserved[r] || il.count() < il.max
+}
+
+// release gives back a reservation that won't be used
+func (il *Interlock) release(r *relay) {
+	il.mu.Lock()
+	delete(il.reserved, r)
+	il.mu.Unlock()
+}
+
+// enqueue holds t until a member switches off
+func (il *Interlock) enqueue(r *relay, t Trigger) {
+	il.mu.Lock()
+	il.queue = append(il.queue, queued{r: r, t: t})
+	il.mu.Unlock()
+}
+
+// drop forgets any queued "On" Triggers for r, as when it is told to switch off
+func (il *Interlock) drop(r *relay) {
+	il.mu.Lock()
+	defer il.mu.Unlock()
+	q := il.queue[:0]
+	for _, e := range il.queue {
+		if e.r != r {
+			q = append(q, e)
+		}
+	}
+	il.queue = q
+}
+
+// transition tracks a member's state and, when one switches off, retries the oldest queued Trigger
+func (il *Interlock) transition(r *relay, on bool) {
+	il.mu.Lock()
+	il.on[r] = on
+	delete(il.reserved, r)
+	var next *queued
+	if !on && len(il.queue) > 0 {
+		next = &queued{}
+		*next = il.queue[0]
+		il.queue = il.queue[1:]
+	}
+	il.mu.Unlock()
+	if next != nil {
+		go next.r.Execute(next.t)
+	}
+}
+
+// admitOn checks every Interlock r belongs to before an "On" Trigger, returning false if t
+// was refused or queued (and reported as such)
+func (r *relay) admitOn(t Trigger) bool {
+	r.mu.Lock()
+	interlocks := r.interlocks
+	r.mu.Unlock()
+	for i, il := range interlocks {
+		if il.admit(r) {
+			continue
+		}
+		for _, prev := range interlocks[:i] {
+			prev.release(r)
+		}
+		limit := strconv.Itoa(il.max)
+		if il.policy == InterlockQueue {
+			il.enqueue(r, t)
+			r.report(t, Report{Kind: ReportInfo, Detail: "On queued, " + limit + " of its interlock group already on"})
+			return false
+		}
+		r.reject(t, "refused On, "+limit+" of its interlock group already on")
+		return false
+	}
+	return true
+}
+
+// interlocked explains why an "On" Trigger would be held by an Interlock right now, or returns ""
+func (r *relay) interlocked() (string, bool) {
+	r.mu.Lock()
+	interlocks := r.interlocks
+	r.mu.Unlock()
+	for _, il := range interlocks {
+		if il.wouldAdmit(r) {
+			continue
+		}
+		limit := strconv.Itoa(il.max)
+		if il.policy == InterlockQueue {
+			return "would queue On, " + limit + " of its interlock group already on", true
+		}
+		return "would refuse On, " + limit + " of its interlock group already on", false
+	}
+	return "", true
+}
+
+// dropQueued forgets r's queued "On" Triggers in every Interlock it belongs to
+func (r *relay) dropQueued() {
+	r.mu.Lock()
+	interlocks := r.interlocks
+	r.mu.Unlock()
+	for _, il := range interlocks {
+		il.drop(r)
+	}
+}
@@ -0,0 +1,52 @@
+package core
+
+import "time"
+
+// LuxSwitch turns a lighting Relay on when the light level falls below one threshold and
+// off when it rises above another, for dusk-to-dawn lights without a scheduler
+type LuxSwitch struct {
+	relay      Relay
+	readings   <-chan float32
+	onBelow    float32
+	offAbove   float32
+	minDwell   time.Duration
+	lastSwitch time.Time
+}
+
+// NewLuxSwitch returns a LuxSwitch for r fed by lux readings from an LDR or light sensor.
+// onBelow should be lower than offAbove; the gap between them is the hysteresis band.
+// The Relay is never switched again within minDwell of its last switch, so passing
+// headlights or clouds don't flicker the load.
+func NewLuxSwitch(r Relay, readings <-chan float32, onBelow, offAbove float32, minDwell time.Duration) *LuxSwitch {
+	return &LuxSwitch{
+		relay:    r,
+		readings: readings,
+		onBelow:  onBelow,
+		offAbove: offAbove,
+		minDwell: minDwell,
+	}
+}
+
+// Run acts on each reading until the readings channel is closed
+func (l *LuxSwitch) Run() {
+	for lux := range l.readings {
+		l.update(lux)
+	}
+}
+
+// update switches the Relay if the reading crosses a threshold and the dwell time has passed
+func (l *LuxSwitch) update(lux float32) {
+	if !l.lastSwitch.IsZero() && since(l.lastSwitch) < l.minDwell {
+		return
+	}
+	on := l.relay.Get()
+	switch {
+	case !on && lux < l.onBelow:
+		l.relay.On()
+	case on && lux > l.offAbove:
+		l.relay.Off()
+	default:
+		return
+	}
+	l.lastSwitch = now()
+}
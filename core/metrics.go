@@ -0,0 +1,110 @@
+package core
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WriteMetrics renders the state, cycle count and on-time of each passed-in Relay
+// in the Prometheus text exposition format, ready to be served from a /metrics endpoint
+func WriteMetrics(w io.Writer, relays ...Relay) error {
+	ss := strings.Builder{}
+	ss.Grow(256 * (len(relays) + 1))
+
+	ss.WriteString("# HELP relay_state Whether the relay is on (1) or off (0).\n")
+	ss.WriteString("# TYPE relay_state gauge\n")
+	for _, r := range relays {
+		v := "0"
+		if r.Get() {
+			v = "1"
+		}
+		writeSample(&ss, "relay_state", r.Name(), v)
+	}
+
+	ss.WriteString("# HELP relay_cycles_total Number of times the relay has switched on.\n")
+	ss.WriteString("# TYPE relay_cycles_total counter\n")
+	for _, r := range relays {
+		writeSample(&ss, "relay_cycles_total", r.Name(), strconv.FormatUint(uint64(r.Stats().Cycles), 10))
+	}
+
+	ss.WriteString("# HELP relay_on_seconds_total Total time the relay has spent on.\n")
+	ss.WriteString("# TYPE relay_on_seconds_total counter\n")
+	for _, r := range relays {
+		writeSample(&ss, "relay_on_seconds_total", r.Name(), strconv.FormatFloat(r.Stats().OnTime.Seconds(), 'f', 3, 64))
+	}
+
+	ss.WriteString("# HELP relay_on_seconds_by_band_total Time the relay has spent on, by tariff band.\n")
+	ss.WriteString("# TYPE relay_on_seconds_by_band_total counter\n")
+	for _, r := range relays {
+		st := r.Stats()
+		for b, d := range st.OnTimeByBand {
+			writeBand(&ss, r.Name(), Band(b), d)
+		}
+	}
+
+	ss.WriteString("# HELP relay_commands_total Triggers handled by the relay, by result.\n")
+	ss.WriteString("# TYPE relay_commands_total counter\n")
+	for _, r := range relays {
+		c := r.Commands()
+		writeResult(&ss, r.Name(), "accepted", c.Accepted)
+		writeResult(&ss, r.Name(), "rejected", c.Rejected)
+		writeResult(&ss, r.Name(), "errored", c.Errored)
+	}
+
+	ss.WriteString("# HELP relay_last_command_timestamp_seconds When the relay last received a Trigger.\n")
+	ss.WriteString("# TYPE relay_last_command_timestamp_seconds gauge\n")
+	for _, r := range relays {
+		last := r.Commands().Last
+		if last.Time.IsZero() {
+			continue
+		}
+		writeSample(&ss, "relay_last_command_timestamp_seconds", r.Name(), strconv.FormatInt(last.Time.Unix(), 10))
+	}
+
+	_, err := io.WriteString(w, ss.String())
+	return err
+}
+
+// writeSample writes one metric line labelled with the relay's name
+func writeSample(ss *strings.Builder, metric, name, value string) {
+	ss.WriteString(metric)
+	ss.WriteString("{relay=\"")
+	ss.WriteString(escapeLabel(name))
+	ss.WriteString("\"} ")
+	ss.WriteString(value)
+	ss.WriteString("\n")
+}
+
+// writeResult writes one relay_commands_total line
+func writeResult(ss *strings.Builder, name, result string, n uint32) {
+	ss.WriteString("relay_commands_total{relay=\"")
+	ss.WriteString(escapeLabel(name))
+	ss.WriteString("\",result=\"")
+	ss.WriteString(result)
+	ss.WriteString("\"} ")
+	ss.WriteString(strconv.FormatUint(uint64(n), 10))
+	ss.WriteString("\n")
+}
+
+// writeBand writes one relay_on_seconds_by_band_total line
+func writeBand(ss *strings.Builder, name string, b Band, d time.Duration) {
+	ss.WriteString("relay_on_seconds_by_band_total{relay=\"")
+	ss.WriteString(escapeLabel(name))
+	ss.WriteString("\",band=\"")
+	ss.WriteString(b.String())
+	ss.WriteString("\"} ")
+	ss.WriteString(strconv.FormatFloat(d.Seconds(), 'f', 3, 64))
+	ss.WriteString("\n")
+}
+
+// escapeLabel escapes a label value as required by the exposition format
+func escapeLabel(s string) string {
+	if !strings.ContainsAny(s, "\\\"\n") {
+		return s
+	}
+	s = strings.ReplaceAll(s, "\\", "\\\\")
+	s = strings.ReplaceAll(s, "\"", "\\\"")
+	return strings.ReplaceAll(s, "\n", "\\n")
+}
@@ -0,0 +1,140 @@
+package core
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Executor is anything that takes Triggers, such as a Relay, a Scheduler or a Chain of Middleware around one
+type Executor interface {
+	Name() string
+	Execute(t Trigger)
+}
+
+// Handler handles a Trigger, as an Executor's Execute does
+type Handler func(t Trigger)
+
+// Middleware wraps a Handler with a concern common to every transport – authentication, logging,
+// rate limiting, rewriting – passing t on to next, changed or not, or refusing it with Refuse
+type Middleware func(next Handler) Handler
+
+// chain is an Executor whose Triggers pass through Middleware on their way in
+type chain struct {
+	x Executor
+	h Handler
+}
+
+// Chain returns x with mw wrapped around its Execute, the first outermost, e.g. for a Dispatcher:
+//
+//	triggerable.Wrap(core.Chain(pump, core.Logging(nil), core.RateLimit(4, time.Minute)))
+func Chain(x Executor, mw ...Middleware) Executor {
+	h := x.Execute
+	for i := len(mw) - 1; i >= 0; i-- {
+		h = mw[i](h)
+	}
+	return chain{x: x, h: h}
+}
+
+// Name returns the wrapped Executor's name
+func (c chain) Name() string {
+	return c.x.Name()
+}
+
+// Execute passes t through the Middleware to the wrapped Executor
+func (c chain) Execute(t Trigger) {
+	c.h(t)
+}
+
+// Refuse reports t as refused, for why, on its ReportCh – or to the console if it has none – without handling it
+func Refuse(t Trigger, why string) {
+	t.Error = true
+	t.Severity = SeverityWarning
+	t.Message = "error - " + t.Target + " refused '" + string(t.Action) + "': " + why
+	reply(t)
+}
+
+// reply sends t on its ReportCh, or prints its Message if it has none
+func reply(t Trigger) {
+	if t.ReportCh == nil {
+		println(t.Message)
+		return
+	}
+	defer func() {
+		if recover() != nil { // the sender closed its ReportCh
+			println(t.Message)
+		}
+	}()
+	t.ReportCh <- t
+}
+
+// Guard refuses Triggers for which check gives a reason, and passes on the rest
+func Guard(check func(t Trigger) (why string)) Middleware {
+	return func(next Handler) Handler {
+		return func(t Trigger) {
+			if why := check(t); why != "" {
+				Refuse(t, why)
+				return
+			}
+			next(t)
+		}
+	}
+}
+
+// AllowActions refuses Triggers whose Action isn't one of actions or an argumented form of one,
+// e.g. AllowActions(ActionOff, ActionAnnounce) for a transport that may only switch things off
+func AllowActions(actions ...Action) Middleware {
+	return Guard(func(t Trigger) string {
+		for _, a := range actions {
+			if t.Action == a || len(t.Action) > len(a) && t.Action[:len(a)+1] == a+" " {
+				return ""
+			}
+		}
+		return "not allowed"
+	})
+}
+
+// Logging passes each Trigger to log as a line of text before handing it on; a nil log prints to the console
+func Logging(log func(line string)) Middleware {
+	if log == nil {
+		log = func(line string) { println(line) }
+	}
+	return func(next Handler) Handler {
+		return func(t Trigger) {
+			line := t.Target + " <- '" + string(t.Action) + "'"
+			if t.Duration != 0 {
+				line += " " + t.Duration.String()
+			}
+			log(line + " from " + t.Source.String())
+			next(t)
+		}
+	}
+}
+
+// RateLimit refuses Triggers beyond n in any period of length per, counted across everything the Chain receives
+func RateLimit(n int, per time.Duration) Middleware {
+	var mu sync.Mutex
+	seen := make([]time.Time, 0, n) // times of the Triggers let through in the last per, oldest first
+	return Guard(func(t Trigger) string {
+		mu.Lock()
+		defer mu.Unlock()
+		at := now()
+		for len(seen) > 0 && at.Sub(seen[0]) >= per {
+			seen = seen[1:]
+		}
+		if len(seen) >= n {
+			return "more than " + strconv.Itoa(n) + " commands in " + per.String()
+		}
+		seen = append(seen, at)
+		return ""
+	})
+}
+
+// Rewrite hands on each Trigger as f returns it, e.g. to map a transport's vocabulary onto Actions
+func Rewrite(f func(t Trigger) Trigger) Middleware {
+	return func(next Handler) Handler {
+		return func(t Trigger) {
+			next(f(t))
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package core
+
+import (
+	"time"
+)
+
+// MinOnPolicy says what happens to an Off request that arrives before a Relay's minimum on-time has elapsed
+type MinOnPolicy uint8
+
+const (
+	MinOnDefer  MinOnPolicy = iota // carry out the Off once the minimum on-time has elapsed
+	MinOnReject                    // refuse the Off
+)
+
+// SetMinOn keeps the Relay's load on for at least d once switched on, protecting loads such as
+// compressors and fluorescent ballasts from short cycling. Shorter runs are lengthened to d, and
+// early Off requests are deferred or refused according to p. 0 removes the minimum.
+func (r *relay) SetMinOn(d time.Duration, p MinOnPolicy) {
+	r.mu.Lock()
+	r.minOn = d
+	r.minOnPolicy = p
+	r.mu.Unlock()
+}
+
+// minOnSetting returns the minimum on-time and its policy
+func (r *relay) minOnSetting() (time.Duration, MinOnPolicy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.minOn, r.minOnPolicy
+}
+
+// holdOn defers or refuses an Off request made before the minimum on-time has elapsed, returning
+// true if it did so; false means the Off may go ahead
+func (r *relay) holdOn(t Trigger) bool {
+	left := r.minOnLeft()
+	if left <= 0 {
+		return false
+	}
+	minOn, policy := r.minOnSetting()
+	elapsed := minOn - left
+	if policy == MinOnReject {
+		r.reject(t, "refused Off after "+elapsed.String()+", its minimum on-time is "+minOn.String())
+		return true
+	}
+	afterFunc(left, func() {
+		r.Execute(t)
+	})
+	r.report(t, Report{Kind: ReportInfo, Detail: "Off deferred by " + left.String() + " to honor its minimum on-time of " + minOn.String()})
+	return true
+}
+
+// minOnLeft returns how long the load must yet stay on to satisfy the minimum on-time
+func (r *relay) minOnLeft() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.minOn <= 0 || !r.on {
+		return 0
+	}
+	return r.minOn - since(r.lastOn)
+}
+
+// describeHold describes what an Off request made now would run into, or returns "" if it would go ahead
+func (r *relay) describeHold() (string, bool) {
+	left := r.minOnLeft()
+	if left <= 0 {
+		return "", true
+	}
+	if minOn, policy := r.minOnSetting(); policy == MinOnReject {
+		return "would refuse Off, its minimum on-time is " + minOn.String(), false
+	}
+	return "would defer Off by " + left.String() + " to honor its minimum on-time", true
+}
@@ -0,0 +1,46 @@
+package core
+
+import (
+	"sync"
+	"time"
+)
+
+// Watch registers f to be called with the Relay's new load state after every transition.
+// f is called from whichever goroutine switched the Relay, so it must not block.
+func (r *relay) Watch(f func(on bool)) {
+	r.mu.Lock()
+	r.watchers = append(r.watchers, f)
+	r.mu.Unlock()
+}
+
+// notify calls the Relay's watchers after a transition
+func (r *relay) notify(on bool) {
+	r.mu.Lock()
+	watchers := r.watchers
+	r.mu.Unlock()
+	for _, f := range watchers {
+		f(on)
+	}
+}
+
+// Mirror makes follower track leader's load state, optionally inverted and after a delay,
+// e.g. for a pilot lamp on a second output or a redundant contactor. If the leader changes
+// again within the delay, only its latest state is applied.
+func Mirror(leader, follower Relay, inverted bool, delay time.Duration) {
+	var mu sync.Mutex
+	var gen uint32
+	follower.Set(leader.Get() != inverted)
+	leader.Watch(func(on bool) {
+		mu.Lock()
+		gen++
+		mine := gen
+		mu.Unlock()
+		afterFunc(delay, func() {
+			mu.Lock()
+			defer mu.Unlock()
+			if mine == gen {
+				follower.Set(on != inverted)
+			}
+		})
+	})
+}
@@ -0,0 +1,35 @@
+package core
+
+// Output is whatever physically drives a Relay: a GPIO pin, a channel on a serial-controlled
+// board, an expander. Set and Get deal in raw levels; polarity and contact wiring are applied
+// by the Relay.
+type Output interface {
+	Configure()
+	Set(level bool)
+	Get() bool
+}
+
+// slower is implemented by Outputs whose Set takes long enough – a radio burst, a network call –
+// that Execute mustn't make it on the caller's behalf
+type slower interface {
+	Slow() bool
+}
+
+// slow reports whether o's Set is too slow for Execute to call
+func slow(o Output) bool {
+	s, ok := o.(slower)
+	return ok && s.Slow()
+}
+
+// describer is implemented by Outputs that can say what they are, for RelayConfig
+type describer interface {
+	String() string
+}
+
+// describe returns o's description, or "" if it has none
+func describe(o Output) string {
+	if d, ok := o.(describer); ok {
+		return d.String()
+	}
+	return ""
+}
@@ -0,0 +1,26 @@
+package core
+
+import "time"
+
+// SetLocalOverride makes the Relay refuse "On" Triggers from anything but a local Source for d after
+// it has been switched off locally, so automation doesn't re-energize a load a person has just
+// switched off at the wall. 0, the default, disables the override window.
+func (r *relay) SetLocalOverride(d time.Duration) {
+	r.do(func() {
+		r.mu.Lock()
+		r.localOverride = d
+		r.mu.Unlock()
+	})
+}
+
+// overridden explains why an "On" from src is suppressed by a local Off, or returns ""
+func (r *relay) overridden(src Source) string {
+	if r.localOverride <= 0 || src.Local() || r.localOff.IsZero() {
+		return ""
+	}
+	ago := since(r.localOff)
+	if ago >= r.localOverride {
+		return ""
+	}
+	return "suppressed On from " + src.String() + ", switched Off locally " + ago.String() + " ago (override for " + r.localOverride.String() + ")"
+}
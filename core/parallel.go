@@ -0,0 +1,120 @@
+package core
+
+import (
+	"sync"
+	"time"
+)
+
+// parallelSettle is how long a contact is given to settle before the load is sensed or the other unit follows
+const parallelSettle = 20 * time.Millisecond
+
+// Parallel is an Output for two relays wired in parallel to one load for redundancy. Each switching
+// is made and broken by one unit alone, alternating between them from cycle to cycle so they share
+// the contact wear of arcing; with hold set, the other unit closes behind it while the load is on,
+// sharing the current. Given a load sense, each switching is checked and a unit that fails to make
+// or break is reported as a FaultFeedback through Diagnose, and named by Failed.
+//
+// Units close on a high level, so don't set the Relay active-low. Set waits for contacts to settle,
+// taking up to 40ms, so an "Off" is left to the Relay's worker.
+type Parallel struct {
+	units  [2]Output
+	hold   bool
+	sense  func() bool // reports whether the load is powered; nil if it can't be sensed
+	mu     sync.Mutex  // guards all below
+	lead   int         // the unit making and breaking the current cycle
+	level  bool
+	failed int // the unit found faulty, or -1
+}
+
+// NewParallel returns a Parallel of units a and b. sense may be nil.
+func NewParallel(a, b Output, hold bool, sense func() bool) *Parallel {
+	return &Parallel{
+		units:  [2]Output{a, b},
+		hold:   hold,
+		sense:  sense,
+		failed: -1,
+	}
+}
+
+func (p *Parallel) Configure() {
+	for _, u := range p.units {
+		u.Configure()
+		u.Set(false)
+	}
+}
+
+func (p *Parallel) Set(level bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if level == p.level {
+		return
+	}
+	p.level = level
+	lead, other := p.units[p.lead], p.units[1-p.lead]
+	if level {
+		lead.Set(true)
+		p.check(true)
+		if p.hold {
+			time.Sleep(parallelSettle)
+			other.Set(true)
+		}
+		return
+	}
+	if p.hold {
+		other.Set(false)
+		time.Sleep(parallelSettle)
+	}
+	lead.Set(false)
+	p.check(false)
+	p.lead = 1 - p.lead // the other unit takes the next cycle
+}
+
+// check senses the load after the lead unit switched to level, noting the unit as failed on a mismatch; p.mu must be held
+func (p *Parallel) check(level bool) {
+	if p.sense == nil {
+		return
+	}
+	time.Sleep(parallelSettle)
+	if p.sense() != level && p.failed < 0 {
+		p.failed = p.lead
+	}
+}
+
+// Slow reports that Set waits for contacts to settle, so an "Off" is left to the worker
+func (p *Parallel) Slow() bool {
+	return true
+}
+
+func (p *Parallel) Get() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.level
+}
+
+// Diagnose returns FaultFeedback once a unit has failed to make or break the load
+func (p *Parallel) Diagnose() Fault {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.failed < 0 {
+		return NoFault
+	}
+	return FaultFeedback
+}
+
+// Failed returns the unit (0 for a, 1 for b) found to have failed, or -1
+func (p *Parallel) Failed() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.failed
+}
+
+// Replaced forgets a failure once the faulty unit has been replaced
+func (p *Parallel) Replaced() {
+	p.mu.Lock()
+	p.failed = -1
+	p.mu.Unlock()
+}
+
+func (p *Parallel) String() string {
+	return "parallel (" + describe(p.units[0]) + ", " + describe(p.units[1]) + ")"
+}
@@ -0,0 +1,199 @@
+package core
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// Op is the operation an action asks for
+type Op uint8
+
+const (
+	OpOn       Op = iota + 1 // "On", "On 30m" or "On indefinitely"
+	OpOff                    // "Off"
+	OpSetting                // one of the Setting* names, with a value
+	OpAnnounce               // ActionAnnounce
+	OpToggle                 // ActionToggle: "On" if the load is off, "Off" if it is on
+	OpPulse                  // "Pulse 200ms": an "On" that must be timed
+)
+
+// ParsedAction is a parsed Trigger action
+type ParsedAction struct {
+	Op         Op
+	DryRun     bool          // the action was prefixed "Validate "
+	Duration   time.Duration // for OpOn the requested run, 0 if none was given; for OpSetting the value
+	Indefinite bool          // for OpOn, "On indefinitely"
+	Setting    string        // for OpSetting, its name
+	Name       string        // for SettingName, the new name
+	Else       Action        // for OpOn and OpOff, the fallback action to take if nothing else arrives within ElseAfter
+	ElseAfter  time.Duration
+}
+
+// Errors wrapped by the ParseErrors that Parse returns
+var (
+	ErrEmptyAction      = errors.New("relay: empty action")
+	ErrNotUnderstood    = errors.New("relay: action not understood")
+	ErrBadDuration      = errors.New("relay: unparsable duration")
+	ErrNegativeDuration = errors.New("relay: negative duration")
+	ErrDurationTooLong  = errors.New("relay: duration too long")
+	ErrTooManyArguments = errors.New("relay: too many arguments")
+	ErrBadFallback      = errors.New("relay: bad fallback")
+	ErrNoDuration       = errors.New("relay: duration required")
+)
+
+// ParseError is an action Parse could not accept; Err is one of the Err values above
+type ParseError struct {
+	Action Action
+	Err    error
+}
+
+// Error describes the problem as a Relay reports it
+func (e *ParseError) Error() string {
+	switch e.Err {
+	case ErrEmptyAction:
+		return "received an empty Action"
+	case ErrBadDuration:
+		return "could not parse the duration in Action '" + string(e.Action) + "'"
+	case ErrNegativeDuration:
+		return "refused negative duration in Action '" + string(e.Action) + "'"
+	case ErrDurationTooLong:
+		return "refused duration in Action '" + string(e.Action) + "', longer than " + MaxDuration.String()
+	case ErrTooManyArguments:
+		return "received too many arguments in Action '" + string(e.Action) + "'"
+	case ErrNoDuration:
+		return "needs a duration in Action '" + string(e.Action) + "'"
+	case ErrBadFallback:
+		return "could not parse the fallback in Action '" + string(e.Action) + "' (<action> " + string(ActionElse+" <"+ActionOn+"|"+ActionOff+"|"+ActionPrevious+"> "+ActionAfter) + " <duration>)"
+	default:
+		return "does not understand Action: '" + string(e.Action) + "' (On, Off)"
+	}
+}
+
+// Unwrap returns the Err value, for errors.Is
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Parse reads a Trigger's action, with d the Trigger's Duration, which supplies the value when the
+// action carries none. It accepts "On", "on" or "ON" with an optional duration or "indefinitely",
+// "Off" likewise, ActionToggle, ActionPulse with a duration, the Setting* names with an optional
+// value, ActionAnnounce, and any of these
+// prefixed "Validate " for a dry run. "On" and "Off" may be followed by a fallback, e.g.
+// "On 10m else Off after 30s", as may a Toggle or Pulse. Any other action, or an unusable duration, gives a *ParseError.
+func Parse(action Action, d time.Duration) (ParsedAction, error) {
+	var a ParsedAction
+	fail := func(err error) (ParsedAction, error) {
+		return ParsedAction{}, &ParseError{Action: action, Err: err}
+	}
+	rest := string(action)
+	if strings.HasPrefix(rest, validatePrefix) {
+		a.DryRun = true
+		rest = strings.TrimPrefix(rest, validatePrefix)
+	}
+	if head, tail, ok := strings.Cut(rest, " "+string(ActionElse)+" "); ok {
+		els, after, ok := strings.Cut(tail, " "+string(ActionAfter)+" ")
+		if !ok {
+			return fail(ErrBadFallback)
+		}
+		d, err := time.ParseDuration(after)
+		if err != nil || d <= 0 || d > MaxDuration {
+			return fail(ErrBadFallback)
+		}
+		if Action(els) != ActionPrevious {
+			e, err := Parse(Action(els), 0)
+			if err != nil || e.DryRun || e.Else != "" || e.Op != OpOn && e.Op != OpOff {
+				return fail(ErrBadFallback)
+			}
+		}
+		a.Else, a.ElseAfter = Action(els), d
+		rest = head
+	}
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return fail(ErrEmptyAction)
+	}
+	if len(fields) > 2 {
+		return fail(ErrTooManyArguments)
+	}
+	switch Action(fields[0]) {
+	case ActionOn, "on", "ON":
+		a.Op = OpOn
+	case ActionOff, "off", "OFF":
+		a.Op = OpOff
+	case ActionToggle:
+		a.Op = OpToggle
+	case ActionPulse:
+		a.Op = OpPulse
+	case SettingDefaultDuration, SettingMaxOn, SettingDutyBudget:
+		a.Op = OpSetting
+		a.Setting = fields[0]
+	case SettingName:
+		if a.Else != "" {
+			return fail(ErrBadFallback)
+		}
+		if len(fields) != 2 {
+			return fail(ErrTooManyArguments)
+		}
+		a.Op = OpSetting
+		a.Setting = fields[0]
+		a.Name = fields[1]
+		return a, nil
+	case ActionAnnounce:
+		a.Op = OpAnnounce
+	default:
+		return fail(ErrNotUnderstood)
+	}
+	if a.Else != "" && a.Op != OpOn && a.Op != OpOff && a.Op != OpToggle && a.Op != OpPulse {
+		return fail(ErrBadFallback)
+	}
+	switch a.Op {
+	case OpOff, OpAnnounce, OpToggle:
+		if len(fields) > 1 {
+			return fail(ErrTooManyArguments)
+		}
+		return a, nil
+	case OpOn:
+		if len(fields) > 1 && Action(fields[1]) == ActionIndefinitely {
+			a.Indefinite = true
+			return a, nil
+		}
+	}
+	if len(fields) > 1 {
+		var err error
+		d, err = time.ParseDuration(fields[1])
+		if err != nil {
+			return fail(ErrBadDuration)
+		}
+	}
+	switch {
+	case d < 0:
+		return fail(ErrNegativeDuration)
+	case (a.Op == OpOn || a.Op == OpPulse) && d > MaxDuration:
+		return fail(ErrDurationTooLong)
+	case a.Op == OpPulse && d == 0:
+		return fail(ErrNoDuration)
+	}
+	a.Duration = d
+	return a, nil
+}
+
+// resolved returns a with a Toggle made an "On" or "Off" for a load that is on or not, and a Pulse
+// made a timed "On"
+func (a ParsedAction) resolved(on bool) ParsedAction {
+	switch {
+	case a.Op == OpToggle && on:
+		a.Op = OpOff
+	case a.Op == OpToggle, a.Op == OpPulse:
+		a.Op = OpOn
+	}
+	return a
+}
+
+// requested returns the run an OpOn Action asks for: its Duration, or indefinite
+func (a ParsedAction) requested() time.Duration {
+	if a.Indefinite {
+		return indefinite
+	}
+	return a.Duration
+}
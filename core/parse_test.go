@@ -0,0 +1,102 @@
+package core_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/eyelight/relay/core"
+	"github.com/eyelight/relay/relaytest"
+)
+
+func TestParse(t *testing.T) {
+	for _, c := range []struct {
+		action core.Action
+		d      time.Duration
+		op     core.Op
+		want   time.Duration
+		err    error
+	}{
+		{action: "On", op: core.OpOn},
+		{action: "on 30m", op: core.OpOn, want: 30 * time.Minute},
+		{action: "On", d: time.Minute, op: core.OpOn, want: time.Minute},
+		{action: "Off", op: core.OpOff},
+		{action: "Off 5m", err: core.ErrTooManyArguments},
+		{action: "Toggle", op: core.OpToggle},
+		{action: "Toggle 5m", err: core.ErrTooManyArguments},
+		{action: "Toggle else Previous after 1m", op: core.OpToggle},
+		{action: "Pulse 200ms", op: core.OpPulse, want: 200 * time.Millisecond},
+		{action: "Pulse", d: time.Second, op: core.OpPulse, want: time.Second},
+		{action: "Pulse", err: core.ErrNoDuration},
+		{action: "Pulse indefinitely", err: core.ErrBadDuration},
+		{action: "Pulse -1s", err: core.ErrNegativeDuration},
+		{action: "Pulse 2161h", err: core.ErrDurationTooLong},
+		{action: "Pulse 10s else Off after 5s", op: core.OpPulse, want: 10 * time.Second},
+		{action: "", err: core.ErrEmptyAction},
+		{action: "Dim 50", err: core.ErrNotUnderstood},
+	} {
+		a, err := core.Parse(c.action, c.d)
+		if !errors.Is(err, c.err) {
+			t.Errorf("Parse(%q, %v) error = %v, want %v", c.action, c.d, err, c.err)
+			continue
+		}
+		if err == nil && (a.Op != c.op || a.Duration != c.want) {
+			t.Errorf("Parse(%q, %v) = op %d for %v, want op %d for %v", c.action, c.d, a.Op, a.Duration, c.op, c.want)
+		}
+	}
+}
+
+func TestToggleAndPulse(t *testing.T) {
+	r := core.New(relaytest.NewOutput(), "fan")
+	r.Configure()
+	r.Execute(core.Trigger{Target: "fan", Action: core.ActionToggle, Source: core.SourceInternal})
+	waitFor(t, "Toggle to switch On", r.Get)
+	r.Execute(core.Trigger{Target: "fan", Action: core.ActionToggle, Source: core.SourceInternal})
+	waitFor(t, "Toggle to switch Off", func() bool { _, ok := r.Remaining(); return !ok && !r.Get() })
+
+	rec := relaytest.NewRecorder()
+	r.Execute(core.Trigger{Target: "fan", Action: "Pulse 20ms", ReportCh: rec.C(), Source: core.SourceInternal})
+	rec.Expect(t, "On for 20ms", time.Second)
+	rec.Expect(t, "Off after", time.Second)
+	rec.ExpectNoErrors(t)
+}
+
+func FuzzParse(f *testing.F) {
+	for _, a := range core.Actions() {
+		f.Add(string(a), int64(0))
+	}
+	for _, a := range []string{
+		"On", "on 30m", "ON indefinitely", "Off", "OFF", "Toggle", "Pulse 200ms", "Announce",
+		"SetDefaultDuration 10m", "SetMaxOn 2h", "SetDutyBudget 4h", "SetName porch",
+		"Validate On 5m", "On 10m else Off after 30s", "Off else Previous after 1m",
+		"On 1h else On 5m after 10s", "Pulse 1s else Off after 2s",
+	} {
+		f.Add(a, int64(0))
+		f.Add(a, int64(time.Minute))
+	}
+	f.Fuzz(func(t *testing.T, action string, d int64) {
+		a, err := core.Parse(core.Action(action), time.Duration(d))
+		if err != nil {
+			var pe *core.ParseError
+			if !errors.As(err, &pe) || pe.Action != core.Action(action) || pe.Error() == "" {
+				t.Fatalf("Parse(%q) error %v is not a *ParseError for the action", action, err)
+			}
+			return
+		}
+		if a.Op < core.OpOn || a.Op > core.OpPulse {
+			t.Fatalf("Parse(%q) = unknown op %d", action, a.Op)
+		}
+		if a.Duration < 0 {
+			t.Fatalf("Parse(%q) = negative duration %v", action, a.Duration)
+		}
+		if (a.Op == core.OpOn || a.Op == core.OpPulse) && a.Duration > core.MaxDuration {
+			t.Fatalf("Parse(%q) = duration %v over MaxDuration", action, a.Duration)
+		}
+		if a.Op == core.OpPulse && a.Duration == 0 {
+			t.Fatalf("Parse(%q) = Pulse without a duration", action)
+		}
+		if a.Else != "" && a.ElseAfter <= 0 {
+			t.Fatalf("Parse(%q) = fallback %q with no wait", action, a.Else)
+		}
+	})
+}
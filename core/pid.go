@@ -0,0 +1,92 @@
+package core
+
+import (
+	"sync"
+	"time"
+)
+
+// PID is a PID controller that reads a process variable (e.g. a water bath temperature)
+// from a channel and writes its output percentage into a TPO engine
+type PID struct {
+	mu       sync.Mutex // guards everything below
+	kp       float32
+	ki       float32
+	kd       float32
+	setpoint float32
+	integral float32
+	prevPV   float32
+	prevTime time.Time
+	pv       <-chan float32
+	out      *TPO
+}
+
+// NewPID returns a PID controller holding setpoint by driving out from the readings received on pv
+func NewPID(kp, ki, kd, setpoint float32, pv <-chan float32, out *TPO) *PID {
+	return &PID{
+		kp:       kp,
+		ki:       ki,
+		kd:       kd,
+		setpoint: setpoint,
+		pv:       pv,
+		out:      out,
+	}
+}
+
+// SetGains retunes the controller while it runs
+func (c *PID) SetGains(kp, ki, kd float32) {
+	c.mu.Lock()
+	c.kp, c.ki, c.kd = kp, ki, kd
+	c.mu.Unlock()
+}
+
+// SetSetpoint changes the value the controller holds the process variable at
+func (c *PID) SetSetpoint(sp float32) {
+	c.mu.Lock()
+	c.setpoint = sp
+	c.mu.Unlock()
+}
+
+// Run feeds every reading received on the process-variable channel through Update into the TPO engine,
+// returning when the channel is closed
+func (c *PID) Run() {
+	for pv := range c.pv {
+		c.out.SetOutput(c.Update(pv, now()))
+	}
+}
+
+// Update returns the output percentage (0-100) for a process-variable reading taken at now.
+// The derivative acts on the measurement so setpoint changes don't kick the output, and the
+// integral only accumulates while the output isn't saturated (anti-windup).
+func (c *PID) Update(pv float32, now time.Time) float32 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	err := c.setpoint - pv
+	var dt, deriv float32
+	if !c.prevTime.IsZero() {
+		dt = float32(now.Sub(c.prevTime).Seconds())
+	}
+	if dt > 0 {
+		deriv = -(pv - c.prevPV) / dt
+	}
+	c.prevPV = pv
+	c.prevTime = now
+
+	out := c.kp*err + c.ki*c.integral + c.kd*deriv
+	if dt > 0 {
+		integral := c.integral + err*dt
+		tentative := c.kp*err + c.ki*integral + c.kd*deriv
+		if (tentative <= 100 || err < 0) && (tentative >= 0 || err > 0) {
+			c.integral = integral
+			out = tentative
+		}
+	}
+
+	if out < 0 {
+		return 0
+	}
+	if out > 100 {
+		return 100
+	}
+	return out
+}
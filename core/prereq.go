@@ -0,0 +1,139 @@
+package core
+
+import (
+	"sync"
+	"time"
+)
+
+// PrereqPolicy says what happens to an "On" Trigger whose prerequisite is not yet met
+type PrereqPolicy uint8
+
+const (
+	PrereqRefuse PrereqPolicy = iota // refuse the Trigger
+	PrereqWait                       // hold the Trigger until the prerequisite has been on long enough; refuse it if the prerequisite is off
+	PrereqStart                      // switch the prerequisite on if it is off, then hold the Trigger as PrereqWait does
+)
+
+// Prerequisite requires one Relay to have been on for a while before another may switch on, e.g.
+// prime a pump for 10s before opening a dosing valve. Should the prerequisite switch off, the
+// dependent Relay is forced off too. It governs "On" Triggers; the low-level On and Set methods
+// bypass it.
+type Prerequisite struct {
+	mu       sync.Mutex
+	before   Relay
+	min      time.Duration
+	policy   PrereqPolicy
+	on       bool
+	since    time.Time
+	starting bool // PrereqStart has switched before on for a held Trigger
+}
+
+// Prime makes before a prerequisite of r: r will only switch on once before has been on for min
+func Prime(r, before Relay, min time.Duration, policy PrereqPolicy) *Prerequisite {
+	p := &Prerequisite{before: before, min: min, policy: policy, on: before.Get(), since: now()}
+	rr, ok := r.(*relay)
+	if !ok {
+		return p
+	}
+	rr.mu.Lock()
+	rr.prereqs = append(rr.prereqs, p)
+	rr.mu.Unlock()
+	before.Watch(func(on bool) {
+		p.mu.Lock()
+		if on != p.on {
+			p.on, p.since = on, now()
+		}
+		p.mu.Unlock()
+		if !on {
+			go rr.do(func() {
+				if rr.on {
+					rr.forceOff(CauseInterlock)
+				}
+			})
+		}
+	})
+	return p
+}
+
+// left returns how much longer the prerequisite must stay on before it is met, or -1 if it is off
+func (p *Prerequisite) left() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.on {
+		return -1
+	}
+	if left := p.min - since(p.since); left > 0 {
+		return left
+	}
+	return 0
+}
+
+// primed checks every Prerequisite of r before an "On" Trigger, returning false if t was refused
+// or held (and reported as such)
+func (r *relay) primed(t Trigger) bool {
+	r.mu.Lock()
+	prereqs := r.prereqs
+	r.mu.Unlock()
+	for _, p := range prereqs {
+		left := p.left()
+		if left == 0 {
+			continue
+		}
+		name := p.before.Name()
+		if left < 0 && p.policy != PrereqStart {
+			r.reject(t, "refused On, its prerequisite "+name+" is off")
+			return false
+		}
+		if left > 0 && p.policy == PrereqRefuse {
+			r.reject(t, "refused On, its prerequisite "+name+" has "+left.String()+" left to prime")
+			return false
+		}
+		if left < 0 {
+			p.mu.Lock()
+			start := !p.starting
+			p.starting = true
+			p.mu.Unlock()
+			if start {
+				go p.before.Execute(Trigger{Target: name, Action: ActionOn, Source: SourceInternal})
+			}
+			left = p.min
+		}
+		afterFunc(left, func() {
+			p.mu.Lock()
+			p.starting = false
+			p.mu.Unlock()
+			if p.left() < 0 { // it never came on, or has since gone off; don't start it again
+				r.reject(t, "refused On, its prerequisite "+name+" did not stay on")
+				return
+			}
+			r.Execute(t)
+		})
+		r.report(t, Report{Kind: ReportInfo, Detail: "On held " + left.String() + " while its prerequisite " + name + " primes"})
+		return false
+	}
+	return true
+}
+
+// describePrereqs describes what an "On" made now would run into, or returns "" if it would go ahead
+func (r *relay) describePrereqs() (string, bool) {
+	r.mu.Lock()
+	prereqs := r.prereqs
+	r.mu.Unlock()
+	for _, p := range prereqs {
+		left := p.left()
+		if left == 0 {
+			continue
+		}
+		name := p.before.Name()
+		switch {
+		case left < 0 && p.policy == PrereqStart:
+			return "would switch its prerequisite " + name + " On and hold On " + p.min.String() + " while it primes", true
+		case left < 0:
+			return "would refuse On, its prerequisite " + name + " is off", false
+		case p.policy == PrereqRefuse:
+			return "would refuse On, its prerequisite " + name + " has " + left.String() + " left to prime", false
+		}
+		return "would hold On " + left.String() + " while its prerequisite " + name + " primes", true
+	}
+	return "", true
+}
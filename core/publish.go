@@ -0,0 +1,99 @@
+package core
+
+import (
+	"sync"
+	"time"
+)
+
+// Publisher emits the state of a list of Relays to a telemetry transport, only for those whose state
+// or fault has changed since they were last published, to spare MQTT or LoRa airtime on large Banks.
+// A Relay that hasn't changed is still published once maxSilence has passed, so a listener can tell
+// a quiet Relay from a lost one; 0 publishes unchanged Relays never.
+type Publisher struct {
+	mu         sync.Mutex
+	relays     []Relay
+	emit       func(index uint8, r Relay) error
+	maxSilence time.Duration
+	last       []published
+	changed    chan struct{}
+	stop       chan struct{}
+}
+
+// published is what a Publisher last emitted for a Relay
+type published struct {
+	ok    bool // emitted at all
+	on    bool
+	fault Fault
+	at    time.Time
+}
+
+// NewPublisher returns a Publisher over relays, indexed as in the list, which calls emit for each
+// Relay it publishes, e.g. to write an EncodeState frame to a radio
+func NewPublisher(emit func(index uint8, r Relay) error, maxSilence time.Duration, relays ...Relay) *Publisher {
+	p := &Publisher{
+		relays:     relays,
+		emit:       emit,
+		maxSilence: maxSilence,
+		last:       make([]published, len(relays)),
+		changed:    make(chan struct{}, 1),
+		stop:       make(chan struct{}),
+	}
+	for _, r := range relays {
+		r.Watch(func(bool) {
+			select {
+			case p.changed <- struct{}{}:
+			default:
+			}
+		})
+	}
+	return p
+}
+
+// Publish emits every Relay that has changed or been silent for maxSilence, returning how many it
+// emitted and the first error emit returned. A Relay whose emit failed is tried again next time.
+func (p *Publisher) Publish() (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var n int
+	var first error
+	at := now()
+	for i, r := range p.relays {
+		cur := published{ok: true, on: r.Get(), fault: r.Fault(), at: at}
+		last := p.last[i]
+		if last.ok && cur.on == last.on && cur.fault == last.fault && (p.maxSilence <= 0 || at.Sub(last.at) < p.maxSilence) {
+			continue
+		}
+		if err := p.emit(uint8(i), r); err != nil {
+			if first == nil {
+				first = err
+			}
+			continue
+		}
+		p.last[i] = cur
+		n++
+	}
+	return n, first
+}
+
+// Run publishes whenever a Relay switches and otherwise every interval, which should be well
+// under maxSilence and short enough to catch faults promptly, until Stop is called
+func (p *Publisher) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := p.Publish(); err != nil {
+			println("error - publishing relay state: " + err.Error())
+		}
+		select {
+		case <-p.changed:
+		case <-ticker.C:
+		case <-p.stop:
+			return
+		}
+	}
+}
+
+// Stop ends Run
+func (p *Publisher) Stop() {
+	close(p.stop)
+}
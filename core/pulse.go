@@ -0,0 +1,37 @@
+package core
+
+import (
+	"errors"
+	"time"
+)
+
+// PulseMax is the longest run handed to a Pulser. Shorter runs – e.g. the set and reset pulses of
+// a latching relay's coils – jitter badly when timed in software under load.
+const PulseMax = 20 * time.Millisecond
+
+// ErrNoPulse is returned by a Pulser that can't time a pulse on this target
+var ErrNoPulse = errors.New("relay: no hardware pulse timing on this target")
+
+// Pulser is implemented by Outputs that can generate a precisely timed pulse, e.g. from a hardware
+// timer. A Relay on such an Output carries out timed runs of up to PulseMax as a single Pulse.
+type Pulser interface {
+	// Pulse sets level for d, then the opposite level; ErrNoPulse leaves the run to software timing
+	Pulse(level bool, d time.Duration) error
+}
+
+// pulsed carries out a short timed run as a single Pulse if the Output can, reporting it as
+// usual; it returns false to leave the run to the software timer
+func (r *relay) pulsed(t Trigger) bool {
+	p, ok := r.out.(Pulser)
+	if !ok || t.Duration <= 0 || t.Duration > PulseMax {
+		return false
+	}
+	r.record(true, causeOf(t.Source))
+	if err := p.Pulse(r.level(true), t.Duration); err != nil {
+		return false // the caller's write(true) drives the output without recording the transition again
+	}
+	r.record(false, CauseTimer)
+	r.report(t, Report{Kind: ReportOff, Elapsed: t.Duration})
+	r.reset()
+	return true
+}
@@ -0,0 +1,36 @@
+package core
+
+import (
+	"sync"
+	"time"
+)
+
+// Purge links fan to heater: the fan switches on with the heater, and when the heater switches off
+// the fan keeps running for the purge duration before it is released, clearing residual heat.
+// If the heater comes back on during the purge, the fan simply stays on.
+func Purge(heater, fan Relay, purge time.Duration) {
+	var mu sync.Mutex
+	var pending Timer
+	heater.Watch(func(on bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		if pending != nil {
+			pending.Stop()
+			pending = nil
+		}
+		if on {
+			go fan.On()
+			return
+		}
+		var t Timer
+		t = afterFunc(purge, func() {
+			mu.Lock()
+			defer mu.Unlock()
+			if pending == t {
+				pending = nil
+				fan.Off()
+			}
+		})
+		pending = t
+	})
+}
@@ -0,0 +1,87 @@
+package core
+
+import "time"
+
+// QuietPolicy says what happens to an "On" Trigger during quiet hours
+type QuietPolicy uint8
+
+const (
+	QuietRefuse QuietPolicy = iota // refuse the On
+	QuietDefer                     // carry out the On when the quiet hours end
+)
+
+// Quiet is a daily window of local time during which a Relay doesn't switch on, e.g. so a noisy
+// pump never runs at night whatever upstream automation asks for. From and To are times of day
+// since midnight; a window may span midnight (From 22h, To 7h). The zero Quiet has no window.
+type Quiet struct {
+	From   time.Duration
+	To     time.Duration
+	Policy QuietPolicy
+}
+
+// left returns how long the window has yet to run at t, or 0 outside it
+func (q Quiet) left(t time.Time) time.Duration {
+	if q.From == q.To {
+		return 0
+	}
+	t = t.Local()
+	tod := t.Sub(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local))
+	in := tod >= q.From && tod < q.To
+	if q.From > q.To {
+		in = tod >= q.From || tod < q.To
+	}
+	if !in {
+		return 0
+	}
+	left := q.To - tod
+	if left < 0 {
+		left += 24 * time.Hour
+	}
+	return left
+}
+
+// SetQuiet gives the Relay quiet hours; the zero Quiet removes them. The On, Off and Set methods
+// are not affected.
+func (r *relay) SetQuiet(q Quiet) {
+	r.do(func() {
+		r.mu.Lock()
+		r.quiet = q
+		r.mu.Unlock()
+	})
+}
+
+// ApplyQuiet gives every Relay in a group the same quiet hours
+func ApplyQuiet(q Quiet, relays ...Relay) {
+	for _, r := range relays {
+		r.SetQuiet(q)
+	}
+}
+
+// holdQuiet defers or refuses an "On" Trigger made during quiet hours, returning true if it did so
+func (r *relay) holdQuiet(t Trigger) bool {
+	left := r.quiet.left(time.Now())
+	if left <= 0 {
+		return false
+	}
+	if r.quiet.Policy == QuietRefuse {
+		r.reject(t, "refused On during quiet hours, which end in "+left.String())
+		return true
+	}
+	time.AfterFunc(left, func() {
+		r.Execute(t)
+	})
+	r.report(t, Report{Kind: ReportInfo, Detail: "On deferred by " + left.String() + " to the end of quiet hours"})
+	return true
+}
+
+// describeQuiet describes what an "On" made now would run into, or returns "" if it would go ahead
+func (r *relay) describeQuiet() (string, bool) {
+	left := r.quiet.left(time.Now())
+	if left <= 0 {
+		return "", true
+	}
+	if r.quiet.Policy == QuietRefuse {
+		return "would refuse On during quiet hours, which end in " + left.String(), false
+	}
+	return "would defer On by " + left.String() + " to the end of quiet hours", true
+}
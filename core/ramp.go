@@ -0,0 +1,102 @@
+package core
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Ramp brings a group of Relays on one at a time, in order, with a dwell between each, and takes
+// them off in the reverse order, so multi-circuit lighting comes up and goes down gracefully from
+// a single command. It takes "On", "On <duration>" – ramping down once the duration is up – and
+// "Off". A command arriving mid-ramp interrupts it; the members already switched stand, and the
+// new ramp starts from its own first step.
+type Ramp struct {
+	name   string
+	relays []Relay
+	dwell  time.Duration
+	mu     sync.Mutex // guards stop
+	stop   chan struct{}
+}
+
+// NewRamp returns a Ramp over relays, switched on in the order given with dwell between each
+func NewRamp(name string, dwell time.Duration, relays ...Relay) *Ramp {
+	return &Ramp{name: name, relays: relays, dwell: dwell}
+}
+
+// Name returns the Ramp's name, and along with Execute lets a Ramp be dispatched to like a Relay
+func (g *Ramp) Name() string {
+	return g.name
+}
+
+// Execute starts ramping the group on or off, reporting that it has to t.ReportCh; the members
+// report their own transitions there too
+func (g *Ramp) Execute(t Trigger) {
+	a, err := Parse(t.Action, t.Duration)
+	switch {
+	case t.Target != g.name:
+		t.Error = true
+		t.Message = "error - " + g.name + " received a trigger intended for " + t.Target
+	case err != nil:
+		t.Error = true
+		t.Message = "error - " + g.name + " refused '" + string(t.Action) + "': " + err.Error()
+	case a.Op != OpOn && a.Op != OpOff:
+		t.Error = true
+		t.Message = "error - " + g.name + " does not understand Action: '" + string(t.Action) + "' (On [duration], Off)"
+	default:
+		g.mu.Lock()
+		if g.stop != nil {
+			close(g.stop)
+		}
+		stop := make(chan struct{})
+		g.stop = stop
+		g.mu.Unlock()
+		var d time.Duration
+		if !a.Indefinite {
+			d = a.Duration
+		}
+		go g.ramp(t, a.Op == OpOn, d, stop)
+		t.Message = g.name + " - ramping " + stateName(a.Op == OpOn) + " over " + strconv.Itoa(len(g.relays)) + " circuits, " + g.dwell.String() + " apart"
+		if d > 0 {
+			t.Message += ", ramping Off after " + d.String()
+		}
+	}
+	if t.ReportCh == nil {
+		println(t.Message)
+		return
+	}
+	t.ReportCh <- t
+}
+
+// ramp switches the members on in order, or off in reverse, until done or stop is closed; an
+// On with a duration ramps down again once it is up
+func (g *Ramp) ramp(t Trigger, on bool, d time.Duration, stop <-chan struct{}) {
+	n := len(g.relays)
+	for i := 0; i < n; i++ {
+		if i > 0 && !g.wait(g.dwell, stop) {
+			return
+		}
+		r := g.relays[i]
+		action := ActionOn
+		if !on {
+			r = g.relays[n-1-i]
+			action = ActionOff
+		}
+		r.Execute(Trigger{Target: r.Name(), Action: action, ReportCh: t.ReportCh, Source: t.Source})
+	}
+	if on && d > 0 && g.wait(d, stop) {
+		g.ramp(t, false, 0, stop)
+	}
+}
+
+// wait waits d, returning false if stop was closed first
+func (g *Ramp) wait(d time.Duration, stop <-chan struct{}) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-stop:
+		return false
+	}
+}
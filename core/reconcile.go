@@ -0,0 +1,43 @@
+package core
+
+import "time"
+
+// SetReconcile re-asserts the Relay's intended state on its Output every interval, recovering from
+// missed pulses or receiver glitches on outputs without readback (latching relays, RF sockets).
+// 0 stops reconciling.
+func (r *relay) SetReconcile(interval time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.reconcileStop != nil {
+		close(r.reconcileStop)
+		r.reconcileStop = nil
+	}
+	r.reconcileEvery = interval
+	if interval > 0 {
+		r.reconcileStop = make(chan struct{})
+		go r.reconcile(interval, r.reconcileStop)
+	}
+}
+
+// reconcile re-asserts the intended state on the worker every interval until stop is closed
+func (r *relay) reconcile(interval time.Duration, stop chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			r.do(func() {
+				if len(r.offCh) > 0 { // an Off has cut the output and awaits the worker
+					return
+				}
+				r.wmu.Lock()
+				if on := r.recordedOn(); !on || r.barred() == "" { // a barred Relay is left to its cut
+					r.out.Set(r.level(on))
+				}
+				r.wmu.Unlock()
+			})
+		}
+	}
+}
@@ -0,0 +1,858 @@
+// Package core holds the machine-independent workings of a relay – timing, state, limits, reporting
+// and Trigger handling – driving any Output. Package relay binds it to TinyGo's GPIO pins; a Relay
+// built here on another Output can sit behind an expander, run on a desktop, or be purely virtual.
+// Package core has no dependencies outside the standard library; package triggerable adapts it to
+// github.com/eyelight/trigger's Dispatcher.
+package core
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type relay struct {
+	name       atomic.Value // of string; written only by the worker, read anywhere through Name
+	out        Output
+	onTime     time.Time     // when the current run or state began; guarded by mu
+	duration   time.Duration // of the current run; guarded by mu
+	wmu        sync.Mutex    // held by write and cut while they drive the output; see barred
+	mu         sync.Mutex    // guards run, seq, history, watchers, tags, commands, interlocks, prereqs, reconciling, diagnosing, repulsing, formatter, fallback, lastTick, rollover, faultLog, faultStore, fuse, fault, shed, shedPrio, defaultDuration, maxOn, minOn, minOnPolicy, retrigger, dutyBudget, dutyStart, dutyBase, remote, pendingElse, nameWatchers, estops, zones, spacer, recordings, and on with the counters Stats reads
+	run        *run
+	seq        uint32
+	history    []Entry // a ring of the Footprint's History size
+	watchers   []func(on bool)
+	tags       map[string]string
+	commands   CommandStats
+	interlocks []*Interlock
+	prereqs    []*Prerequisite
+	shedPrio   uint8
+	shed       bool
+
+	reconcileEvery time.Duration
+	reconcileStop  chan struct{}
+	diagnoseEvery  time.Duration
+	diagnoseStop   chan struct{}
+	repulseEvery   time.Duration
+	repulseStop    chan struct{}
+	formatter      Formatter
+	fallback       chan Trigger // reports for Triggers without a ReportCh; nil prints them
+	cmd            chan command
+	offCh          chan command // a pending "Off", handled ahead of cmd
+	ctl            chan func()
+	arrivals       uint32 // Triggers numbered by Execute; atomic
+	lastOff        uint32 // arrival number of the latest "Off"; atomic
+	gen            uint32 // generation of the current worker, advanced by ForceReset; atomic
+	droppedOffs    uint32 // "Off" Triggers dropped because one was already waiting; atomic
+	droppedReports uint32 // reports that could not be delivered; atomic
+	dropOuts       uint32 // times the Output was found dropped out while on; atomic
+	busy           uint32 // Triggers refused because the queue was full; atomic
+	lastTick       time.Time
+	on             bool
+	lastOn         time.Time
+	cycles         uint32
+	onTotal        time.Duration
+	dayCycles      uint32        // Cycles at the last daily reset
+	dayOnTime      time.Duration // OnTime at the last daily reset
+	energyOnTime   time.Duration // OnTime at the last energy reset
+	watts          float32
+	rollover       *time.Timer
+	fuse           *fuse
+	fault          Fault
+	activeLow      uint32 // 1 when the coil is energized by a low pin; atomic
+	nc             uint32 // 1 when the load is on the normally-closed contact; atomic
+
+	defaultDuration time.Duration
+	maxOn           time.Duration
+	dutyBudget      time.Duration
+	dutyStart       time.Time
+	dutyBase        time.Duration   // on-time already accumulated when the duty window started
+	remote          map[string]bool // settings that may be changed through Triggers
+	minOn           time.Duration
+	minOnPolicy     MinOnPolicy
+	retrigger       bool
+	vote            *Vote
+	source          Source       // of the Trigger being handled; guarded by mu
+	sourcePolicy    SourcePolicy // written by the worker under mu; Offer reads it
+	localOverride   time.Duration
+	localOff        time.Time // when the Relay was last switched off from a local Source
+	quiet           Quiet
+	faultLog        []FaultRecord
+	faultStore      Store
+	pendingElse     Timer // the fallback armed by the latest "On" or "Off"
+	footprint       Footprint
+	nameWatchers    []func(old, name string)
+	estops          []*EStop
+	zones           []string // e-stop zones
+	spacer          *spacer  // shared by a Bank's Relays; see SetSpacing
+	recordings      []*Recording
+	peakPolicy      PeakPolicy
+	byBand          [BandCount]time.Duration // on-time by tariff Band, up to the last switch-off
+	energyBandBase  [BandCount]time.Duration // on-time by Band at the last energy reset
+}
+
+// run holds the channels of one timed or indefinite "on" period. Only the goroutine
+// running it closes done, and durationCh & off are never closed, so senders can't panic.
+type run struct {
+	durationCh chan time.Duration
+	off        chan Cause
+	done       chan struct{}
+}
+
+type Relay interface {
+	Configure()
+	Get() bool
+	Set(bool) bool
+	On() bool
+	Off() bool
+	Name() string
+	Execute(t Trigger)
+	Offer(t Trigger) error
+	State() (interface{}, time.Time)
+	StateString() string
+	DurationCh() chan time.Duration
+	Stats() Stats
+	SetFuse(s CurrentSensor, limit float32, grace time.Duration)
+	Fault() Fault
+	ClearFault()
+	SetActiveLow(bool)
+	SetNormallyClosed(bool)
+	Coil() bool
+	Load() bool
+	Config() RelayConfig
+	SetDefaultDuration(time.Duration)
+	SetMaxOn(time.Duration)
+	SetDutyBudget(time.Duration)
+	AllowRemote(settings ...string)
+	History() []Entry
+	Seq() uint32
+	Validate(t Trigger) (string, bool)
+	Watch(f func(on bool))
+	SetMinOn(d time.Duration, p MinOnPolicy)
+	SetTag(key, value string)
+	Tags() map[string]string
+	Remaining() (time.Duration, bool)
+	Commands() CommandStats
+	SetShedPriority(p uint8)
+	ShedPriority() uint8
+	Shed() bool
+	SetReconcile(interval time.Duration)
+	SetFormatter(f Formatter)
+	SetReportFallback(ch chan Trigger)
+	SetRetrigger(on bool)
+	Health() Health
+	ForceReset()
+	SetLoadPower(watts float32)
+	ResetStats(scope StatsScope)
+	SetRollover(on bool)
+	SetVote(v *Vote)
+	SetSourcePolicy(p SourcePolicy)
+	SetLocalOverride(d time.Duration)
+	Trip(f Fault, detail string)
+	SetDiagnostics(interval time.Duration)
+	SetQuiet(q Quiet)
+	FaultHistory() []FaultRecord
+	SetFaultStore(s Store) error
+	SetName(name string) error
+	WatchName(f func(old, name string))
+	SetEStopZones(zones ...string)
+	SetRepulse(interval time.Duration)
+	SetPeakPolicy(p PeakPolicy)
+}
+
+// Stats holds the counters accumulated by a Relay since it was created, or since they were reset
+type Stats struct {
+	Cycles    uint32        // number of off-to-on transitions
+	OnTime    time.Duration // total time spent on, including the current run
+	DayCycles uint32        // Cycles since the last daily reset or rollover
+	DayOnTime time.Duration // OnTime since the last daily reset or rollover
+	Energy    float32       // watt-hours used since the last energy reset, at the load power; 0 if it isn't set
+
+	OnTimeByBand [BandCount]time.Duration // OnTime broken down by tariff Band, since SetTariff
+	EnergyByBand [BandCount]float32       // Energy broken down by tariff Band, since SetTariff
+}
+
+// New returns a Relay driven through o, ready to be configured
+func New(o Output, name string) Relay {
+	f := Footprint(atomic.LoadUint32(&footprint))
+	r := &relay{
+		out:       o,
+		onTime:    time.Time{},
+		duration:  0 * time.Second,
+		run:       nil,
+		history:   make([]Entry, f.Sizes().History),
+		cmd:       make(chan command, f.Sizes().Queue),
+		offCh:     make(chan command, 1),
+		ctl:       make(chan func()),
+		shedPrio:  ShedNever,
+		footprint: f,
+	}
+	r.name.Store(name)
+	go r.work(0)
+	return r
+}
+
+// Configure sets up the Relay for use, beginning in the "Off" state
+func (r *relay) Configure() {
+	r.out.Configure()
+	r.out.Set(r.level(false)) // whatever the Output reads back, e.g. a socket left on before a reboot
+	r.Off()
+	r.setOnTime(now())
+}
+
+// DurationCh returns the channel on which the current run accepts a revised duration, or nil when the Relay is idle
+func (r *relay) DurationCh() chan time.Duration {
+	if run := r.current(); run != nil {
+		return run.durationCh
+	}
+	return nil
+}
+
+// Execute acts on input from a Trigger; wrapped by package triggerable, it and Name implement trigger.Triggerable.
+// Triggers are queued to the Relay's worker, so Execute may be called from any goroutine.
+//
+// An "Off" Trigger is the exception: unless the minimum on-time holds the load on, Execute drives the
+// output to its off level before handing the Trigger over, so the load drops within one Output.Set of
+// the call (microseconds for a GPIO pin, ~5ms for a UART board at 9600 baud) however busy the worker is.
+// Outputs too slow for that – an RF433 socket, a virtual Relay's callbacks – are switched off by the worker.
+// Execute never blocks or sleeps for an "Off", so it may be called from an interrupt handler; the worker
+// takes it ahead of queued Triggers, skips any "On" queued before it, ends the run and acknowledges the
+// cancellation on the Trigger's ReportCh. If an "Off" is already waiting, a second one is dropped.
+//
+// Execute doesn't block for other Triggers either: if the Relay's queue is full, the Trigger is
+// refused as busy, with the queue depth, on its ReportCh if it has room; see Offer. Only Triggers from
+// SourceInternal and SourceSchedule, which the package makes itself, wait for room.
+func (r *relay) Execute(t Trigger) {
+	r.Offer(t)
+}
+
+// Offer is Execute returning ErrBusy when the Relay's queue is full, so a transport flooded with
+// commands can tell and back off; the Trigger is also refused on its ReportCh, as with Execute
+func (r *relay) Offer(t Trigger) error {
+	c := command{t: t, n: atomic.AddUint32(&r.arrivals, 1)}
+	if t.Target == r.Name() && isOff(t.Action) {
+		if r.minOnLeft() <= 0 && !slow(r.out) && r.permits(t) {
+			r.cut()
+		}
+		atomic.StoreUint32(&r.lastOff, c.n)
+		select {
+		case r.offCh <- c:
+		default:
+			atomic.AddUint32(&r.droppedOffs, 1)
+		}
+		return nil
+	}
+	if t.Source == SourceInternal || t.Source == SourceSchedule {
+		r.cmd <- c // the package's own Triggers wait their turn
+		return nil
+	}
+	select {
+	case r.cmd <- c:
+		return nil
+	default:
+	}
+	atomic.AddUint32(&r.busy, 1)
+	r.rejectNow(t, "refused, busy with "+strconv.Itoa(len(r.cmd))+" commands queued")
+	return ErrBusy
+}
+
+// permits reports whether the Relay's SourcePolicy, if any, allows t, so Offer doesn't cut the
+// output for an "Off" the worker is going to refuse
+func (r *relay) permits(t Trigger) bool {
+	r.mu.Lock()
+	p := r.sourcePolicy
+	r.mu.Unlock()
+	if p == nil {
+		return true
+	}
+	a, err := Parse(t.Action, t.Duration)
+	return err == nil && p(t.Source, a) == ""
+}
+
+// ErrBusy is returned by Offer when the Relay's queue is full
+var ErrBusy = errors.New("relay: busy")
+
+// command is a Trigger numbered in order of arrival at Execute
+type command struct {
+	t Trigger
+	n uint32
+}
+
+// isOn reports whether action is an "On" action, with or without a duration, e.g. "On 30m"
+func isOn(action Action) bool {
+	fields := strings.Fields(string(action))
+	if len(fields) == 0 {
+		return false
+	}
+	switch Action(fields[0]) {
+	case ActionOn, "on", "ON", ActionPulse:
+		return true
+	}
+	return false
+}
+
+// isOff reports whether action is an "Off" action
+func isOff(action Action) bool {
+	switch action {
+	case ActionOff, "off", "OFF":
+		return true
+	}
+	return false
+}
+
+// cut drives the output to the off level immediately, from any goroutine; the bookkeeping
+// (counters, history, reports) is left to whoever calls write(false, ...) afterwards
+func (r *relay) cut() {
+	r.wmu.Lock()
+	r.out.Set(r.level(false))
+	r.wmu.Unlock()
+}
+
+// work handles queued Triggers one at a time until a ForceReset replaces it with a worker of a new generation
+func (r *relay) work(gen uint32) {
+	for atomic.LoadUint32(&r.gen) == gen {
+		select {
+		case c := <-r.offCh:
+			r.tick()
+			r.handle(c.t)
+			continue
+		default:
+		}
+		select {
+		case c := <-r.offCh:
+			r.tick()
+			r.handle(c.t)
+		case c := <-r.cmd:
+			r.tick()
+			if c.n < atomic.LoadUint32(&r.lastOff) && isOn(c.t.Action) {
+				r.reject(c.t, "skipped On, superseded by a later Off")
+				continue
+			}
+			r.handle(c.t)
+		case f := <-r.ctl:
+			r.tick()
+			f()
+		}
+	}
+}
+
+// do runs f on the Relay's worker, between Triggers, and waits for it to finish
+func (r *relay) do(f func()) {
+	done := make(chan struct{})
+	r.ctl <- func() {
+		f()
+		close(done)
+	}
+	<-done
+}
+
+// forceOff ends any run and switches the load off, bypassing the minimum on-time; it is only ever called from the worker
+func (r *relay) forceOff(c Cause) {
+	r.dropQueued()
+	if run := r.current(); run != nil {
+		run.cancel(c)
+		<-run.done
+	}
+	if r.on { // the output may already have been cut
+		r.write(false, c)
+		r.reset()
+	}
+}
+
+// handle acts on a single Trigger; it is only ever called from the worker
+func (r *relay) handle(t Trigger) {
+	println("relay.handle()...")
+	r.received(t)
+	if t.Target != r.Name() {
+		println("error - " + r.Name() + " received a trigger intended for " + t.Target)
+		r.fail(t, "received a trigger intended for "+t.Target)
+		return
+	}
+	if strings.HasPrefix(string(t.Action), validatePrefix) {
+		r.handleValidate(t)
+		return
+	}
+	a, err := Parse(t.Action, t.Duration)
+	if err != nil {
+		r.fail(t, err.Error())
+		return
+	}
+	a = a.resolved(r.Get())
+	if r.sourcePolicy != nil {
+		if refusal := r.sourcePolicy(t.Source, a); refusal != "" {
+			r.reject(t, refusal)
+			return
+		}
+	}
+	cause := causeOf(t.Source)
+	switch a.Op {
+	case OpOn:
+		if refusal := r.overridden(t.Source); refusal != "" {
+			r.reject(t, refusal)
+			return
+		}
+		if refusal := r.refuseOn(); refusal != "" {
+			r.reject(t, refusal)
+			return
+		}
+		if r.holdQuiet(t) {
+			return
+		}
+		if r.holdPeak(t) {
+			return
+		}
+		if r.current() == nil && !r.primed(t) {
+			return
+		}
+		if r.current() == nil && !r.admitOn(t) {
+			return
+		}
+		// only now it has been accepted; one held above comes back through here
+		r.armElse(t, a)
+		r.recordCommand(t, a)
+		t.Error = false
+		t.Duration = r.limit(a.requested())
+		r.audit(EntryCommand, string(ActionOn)+" "+durationString(t.Duration), cause)
+		if r.current() == nil { // there is no run while the below goroutine is not actively working
+			r.setOnTime(now())
+			if r.pulsed(t) {
+				return
+			}
+			r.write(true, cause)
+			// the run is in place before the next Trigger is handled
+			run := r.start()
+			go func() {
+				defer println("	relay.handle() routine exiting.")
+				defer r.finish(run)
+				defer println("	Before reset" + r.Name() + " duration: " + r.runDuration().String())
+				defer println("	Before reset" + r.Name() + " onTime: " + r.started().Local().Format(time.RFC822))
+				defer println("	Before reset" + r.Name() + " working: " + strconv.FormatBool(r.current() != nil))
+
+				// r.setOnTime(now())
+				// r.pin.High()
+
+				// the expiry timer starts before the first report so a slow reader can't stretch a short run
+				expiry := newExpiry(t.Duration)
+				defer expiry.stop()
+				var sample <-chan time.Time // fuse sampling, only while a fuse is fitted
+				fu := r.currentFuse()
+				if fu != nil {
+					ticker := time.NewTicker(fuseSampleInterval)
+					defer ticker.Stop()
+					sample = ticker.C
+				}
+
+				// determined duration or indeterminate
+				if t.Duration <= 0 { // sending a command with a negative or omitted duration will be treated as "indefinite on"
+					r.report(t, Report{Kind: ReportOn, Time: r.started()})
+					// return
+				} else {
+					r.setDuration(t.Duration)
+					r.report(t, Report{Kind: ReportOn, Duration: t.Duration, Time: r.started()})
+				}
+
+				// wait for communication or off time
+				for {
+					select {
+					case c := <-run.off:
+						r.write(false, c)
+						r.report(t, Report{Kind: ReportForcedOff, Elapsed: r.elapsed()})
+						return
+					case newDuration := <-run.durationCh:
+						if newDuration == indefinite {
+							expiry.clear()
+							r.setDuration(0)
+							r.report(t, Report{Kind: ReportOn, Time: r.started()})
+							continue
+						}
+						if newDuration <= 0 {
+							r.write(false, cause)
+							r.report(t, Report{Kind: ReportOff, Elapsed: r.elapsed()})
+							return
+						}
+						rep := Report{Kind: ReportDuration, Duration: newDuration, Previous: r.runDuration(), Elapsed: r.elapsed()}
+						r.setDuration(newDuration)
+						expiry.reset(newDuration - r.elapsed())
+						r.report(t, rep)
+					case <-sample:
+						if r.fuseTripped(fu) {
+							r.write(false, CauseFuse)
+							r.report(t, Report{Kind: ReportFault, Error: true, Fault: r.Fault(), Detail: fu.message(), Elapsed: r.elapsed()})
+							return
+						}
+					case <-expiry.c():
+						r.write(false, CauseTimer)
+						r.report(t, Report{Kind: ReportOff, Elapsed: r.elapsed()})
+						return
+					}
+				}
+			}()
+			// t.Message = string(r.Name() + " - On at " + r.onTime.Local().Format(time.RFC822))
+			// t.ReportCh <- t
+			println("	relay.handle returning from On + spawning goroutine")
+			return
+		} else {
+			if t.Duration == 0 && r.holdOn(t) {
+				return
+			}
+			if r.retriggers() && t.Duration > 0 {
+				t.Duration = r.retriggered(t.Duration)
+			}
+			if t.Duration != r.runDuration() {
+				println("	relay.handle sending new duration of " + t.Duration.String() + " to " + r.Name())
+				if run := r.current(); run != nil {
+					run.send(t.Duration)
+				}
+				return
+			}
+		}
+	case OpOff:
+		if r.holdOn(t) {
+			return
+		}
+		r.armElse(t, a)
+		r.recordCommand(t, a)
+		r.dropQueued()
+		r.audit(EntryCommand, string(ActionOff), cause)
+		if t.Source.Local() {
+			r.localOff = now()
+		}
+		if run := r.current(); run != nil {
+			println("sending off signal to " + r.Name())
+			run.cancel(cause) // an existing "on" goroutine should be canceled & the relay reset
+			<-run.done
+		}
+		if r.on { // the output may already have been cut by Execute
+			r.write(false, cause)
+			println("Off handler forcing " + r.Name() + " off")
+			r.report(t, Report{Kind: ReportOff, Elapsed: r.elapsed()})
+			r.reset()
+			return
+		}
+		return
+	case OpAnnounce:
+		r.announce(t)
+	case OpSetting:
+		r.handleSetting(t, a)
+	}
+}
+
+// SetActiveLow declares that the Relay's coil is energized by driving its pin low, as on many
+// opto-isolated relay boards, and re-drives the pin to keep the Relay's current state
+func (r *relay) SetActiveLow(activeLow bool) {
+	r.wmu.Lock()
+	defer r.wmu.Unlock()
+	atomic.StoreUint32(&r.activeLow, flag(activeLow))
+	r.out.Set(r.level(r.recordedOn()))
+}
+
+// SetNormallyClosed declares that the load is wired to the Relay's normally-closed contact, so the
+// load is on while the coil is de-energized. On, Off, Get, State and reports then all refer to the
+// load rather than the coil; the pin is re-driven to keep the load's current state.
+func (r *relay) SetNormallyClosed(nc bool) {
+	r.wmu.Lock()
+	defer r.wmu.Unlock()
+	atomic.StoreUint32(&r.nc, flag(nc))
+	r.out.Set(r.level(r.recordedOn()))
+}
+
+// Get returns a measured reading of the Relay's pin as the state of the load, true meaning on
+func (r *relay) Get() bool {
+	return r.Coil() != (atomic.LoadUint32(&r.nc) == 1)
+}
+
+// Coil returns a measured reading of the Relay's pin as the state of the coil, true meaning energized
+func (r *relay) Coil() bool {
+	return r.out.Get() != (atomic.LoadUint32(&r.activeLow) == 1)
+}
+
+// Load returns a measured reading of the Relay's pin as the state of the load, true meaning on; it is the same as Get
+func (r *relay) Load() bool {
+	return r.Get()
+}
+
+// Set puts the Relay's load in the passed-in state and returns a subsequent, measured confirmation;
+// an e-stopped, shed or faulted Relay stays off
+func (r *relay) Set(s bool) bool {
+	r.write(s, CauseDirect)
+	r.setOnTime(now())
+	time.Sleep(5 * time.Millisecond)
+	return r.Get()
+}
+
+// On switches the Relay's load on and returns a subsequent, measured confirmation; an e-stopped,
+// shed or faulted Relay stays off
+func (r *relay) On() bool {
+	r.write(true, CauseDirect)
+	r.setOnTime(now())
+	time.Sleep(5 * time.Millisecond)
+	return r.Get()
+}
+
+// Off switches the Relay's load off and returns a subsequent, measured confirmation
+func (r *relay) Off() bool {
+	r.write(false, CauseDirect)
+	r.setOnTime(now())
+	time.Sleep(5 * time.Millisecond)
+	return r.Get()
+}
+
+/*
+	Statist interface methods
+	State() (interface{}, time.Time)
+	SetState(interface{}, time.Time)
+	StateString() string
+	Name() string
+*/
+
+// Stats returns the Relay's cycle count and accumulated on-time
+func (r *relay) Stats() Stats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.stats()
+}
+
+// stats must be called with r.mu held
+func (r *relay) stats() Stats {
+	st := Stats{Cycles: r.cycles, OnTime: r.onTotal}
+	if r.on {
+		st.OnTime += since(r.lastOn)
+	}
+	st.DayCycles = st.Cycles - r.dayCycles
+	st.DayOnTime = st.OnTime - r.dayOnTime
+	st.Energy = float32((st.OnTime - r.energyOnTime).Hours()) * r.watts
+	st.OnTimeByBand = r.byBand
+	if r.on {
+		currentTariff().split(r.lastOn, now(), func(b Band, d time.Duration) { st.OnTimeByBand[b] += d })
+	}
+	for b := range st.OnTimeByBand {
+		st.EnergyByBand[b] = float32((st.OnTimeByBand[b] - r.energyBandBase[b]).Hours()) * r.watts
+	}
+	return st
+}
+
+// State returns a Relay's state as a bool and the time since this state has been valid
+func (r *relay) State() (interface{}, time.Time) {
+	return r.Get(), r.started()
+}
+
+// StateString returns a Relay's state and the time since this has been valid as a string
+func (r *relay) StateString() string {
+	s := string(StateOn)
+	if !r.Get() {
+		s = string(StateOff)
+	}
+	ss := strings.Builder{}
+	ss.Grow(1024)
+	ss.WriteString(stamp(now()))
+	ss.WriteString(" -- (Relay) ")
+	ss.WriteString(r.Name())
+	ss.WriteString(" ")
+	ss.WriteString(s)
+	ss.WriteString(" since ")
+	ss.WriteString(stamp(r.started()))
+	r.writeTags(&ss)
+	return ss.String()
+}
+
+// Name returns the relay's name and along with relay.Execute() implements the Triggerable interface
+func (r *relay) Name() string {
+	return r.name.Load().(string)
+}
+
+// write drives the Relay's pin to put the load in state s, unless it is there already, and keeps the
+// cycle and on-time counters. It won't switch the load on while the Relay is barred, whichever path
+// – a Trigger, On, Set, a TPO – asked for it.
+func (r *relay) write(s bool, c Cause) {
+	if r.out.Get() != r.level(s) {
+		r.space()
+	}
+	r.wmu.Lock()
+	if s && r.barred() != "" {
+		r.wmu.Unlock()
+		return
+	}
+	if r.out.Get() != r.level(s) { // an Off cut by Execute is already at its level
+		r.out.Set(r.level(s))
+	}
+	changed := r.mark(s)
+	r.wmu.Unlock()
+	if changed {
+		r.transition(s, c)
+	}
+}
+
+// barred explains why the Relay may not be switched on at all – an e-stop, shedding or a Fault – or
+// returns "". Each of these is latched before the output is cut, and write checks it and drives the
+// output under wmu, so a write can't slip in between a latch and its cut.
+func (r *relay) barred() string {
+	if refusal := r.estopRefusal(); refusal != "" {
+		return refusal
+	}
+	r.mu.Lock()
+	shed, prio, fault := r.shed, r.shedPrio, r.fault
+	r.mu.Unlock()
+	if shed {
+		return shedRefusal(prio)
+	}
+	if fault != NoFault {
+		return "refused On while in " + fault.String() + " fault"
+	}
+	return ""
+}
+
+// record does the bookkeeping of a transition to s for cause c – counters, history and watchers –
+// without driving the output
+func (r *relay) record(s bool, c Cause) {
+	if r.mark(s) {
+		r.transition(s, c)
+	}
+}
+
+// mark updates the counters for a transition to s, returning whether the state changed
+func (r *relay) mark(s bool) bool {
+	r.mu.Lock()
+	if s && !r.on {
+		r.cycles++
+		r.lastOn = now()
+	} else if !s && r.on {
+		r.onTotal += since(r.lastOn)
+		currentTariff().split(r.lastOn, now(), func(b Band, d time.Duration) { r.byBand[b] += d })
+	}
+	changed := s != r.on
+	r.on = s
+	r.mu.Unlock()
+	return changed
+}
+
+// transition records a transition to s for cause c in the history and tells the watchers
+func (r *relay) transition(s bool, c Cause) {
+	if s {
+		r.audit(EntryTransition, "On", c)
+	} else {
+		r.audit(EntryTransition, "Off", c)
+	}
+	r.notify(s)
+}
+
+// recordedOn returns whether the Relay's bookkeeping has the load on, whatever the output reads
+func (r *relay) recordedOn() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.on
+}
+
+// level returns the pin level that puts the load in state s, allowing for polarity and contact wiring
+func (r *relay) level(s bool) bool {
+	return s != (atomic.LoadUint32(&r.nc) == 1) != (atomic.LoadUint32(&r.activeLow) == 1)
+}
+
+// flag returns b as 1 or 0 for an atomic field
+func flag(b bool) uint32 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// current returns the Relay's active run, or nil when it is idle
+func (r *relay) current() *run {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.run
+}
+
+// start makes a new run the Relay's active run and returns it
+func (r *relay) start() *run {
+	run := &run{
+		durationCh: make(chan time.Duration, 1),
+		off:        make(chan Cause, 1),
+		done:       make(chan struct{}),
+	}
+	r.mu.Lock()
+	r.run = run
+	r.mu.Unlock()
+	return run
+}
+
+// finish detaches a run from the Relay and closes its done channel; only the run's own goroutine calls it
+func (r *relay) finish(run *run) {
+	r.mu.Lock()
+	current := r.run == run
+	if current {
+		r.run = nil
+	}
+	r.mu.Unlock()
+	if current { // a run abandoned by ForceReset leaves the Relay alone
+		r.reset()
+	}
+	close(run.done)
+}
+
+// send passes a revised duration to the run, returning false if the run has already finished
+func (run *run) send(d time.Duration) bool {
+	select {
+	case run.durationCh <- d:
+		return true
+	case <-run.done:
+		return false
+	}
+}
+
+// cancel asks the run to switch off for cause c, returning false if the run has already finished
+func (run *run) cancel(c Cause) bool {
+	select {
+	case run.off <- c:
+		return true
+	case <-run.done:
+		return false
+	}
+}
+
+// reset zeroes the timing fields of a relay struct
+func (r *relay) reset() {
+	println("					resetting " + r.Name())
+	r.mu.Lock()
+	r.duration = time.Duration(0)
+	r.onTime = time.Time{}
+	r.mu.Unlock()
+	println("					" + r.Name() + " duration: 0s")
+	println("					" + r.Name() + " onTime: " + time.Time{}.Local().Format(time.RFC822))
+	println("					" + r.Name() + " working: " + strconv.FormatBool(r.current() != nil))
+}
+
+// started returns when the current run or state began
+func (r *relay) started() time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.onTime
+}
+
+// elapsed returns the time since the current run or state began
+func (r *relay) elapsed() time.Duration {
+	return since(r.started())
+}
+
+// setOnTime records when the current run or state began
+func (r *relay) setOnTime(t time.Time) {
+	r.mu.Lock()
+	r.onTime = t
+	r.mu.Unlock()
+}
+
+// runDuration returns the duration of the current run; 0 for an indefinite run or none
+func (r *relay) runDuration() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.duration
+}
+
+// setDuration records the duration of the current run
+func (r *relay) setDuration(d time.Duration) {
+	r.mu.Lock()
+	r.duration = d
+	r.mu.Unlock()
+}
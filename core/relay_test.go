@@ -0,0 +1,77 @@
+package core_test
+
+import (
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/eyelight/relay/core"
+	"github.com/eyelight/relay/relaytest"
+)
+
+func TestRemainingWhileRevising(t *testing.T) {
+	r := core.New(relaytest.NewOutput(), "lamp")
+	r.Configure()
+	r.Execute(core.Trigger{Target: "lamp", Action: core.ActionOn, Duration: time.Hour, Source: core.SourceInternal})
+	waitFor(t, "On", r.Get)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 1; i <= 50; i++ {
+			r.Execute(core.Trigger{Target: "lamp", Action: core.ActionOn, Duration: time.Hour + time.Duration(i)*time.Minute, Source: core.SourceInternal})
+		}
+	}()
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+			r.Remaining()
+			r.State()
+		}
+	}
+	waitFor(t, "the last revision", func() bool {
+		left, ok := r.Remaining()
+		return ok && left > time.Hour+49*time.Minute
+	})
+	r.Execute(core.Trigger{Target: "lamp", Action: core.ActionOff, Source: core.SourceInternal})
+	waitFor(t, "Off", func() bool { _, ok := r.Remaining(); return !ok })
+}
+
+func TestBusyRefusalsDontBlockOrPileUp(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	r := core.NewVirtual("socket",
+		func() error { close(started); <-release; return nil },
+		func() error { return nil })
+	r.Configure()
+	reports := make(chan core.Trigger) // nobody reads it until the end
+	r.Execute(core.Trigger{Target: "socket", Action: core.ActionOn, Source: core.SourceRemote, ReportCh: reports})
+	<-started
+
+	goroutines := runtime.NumGoroutine()
+	busy := 0
+	for i := 0; i < 500; i++ {
+		err := r.Offer(core.Trigger{Target: "socket", Action: core.ActionOn, Source: core.SourceRemote, ReportCh: reports})
+		if errors.Is(err, core.ErrBusy) {
+			busy++
+		}
+	}
+	if busy == 0 {
+		t.Fatal("no Trigger was refused as busy")
+	}
+	if n := runtime.NumGoroutine(); n > goroutines+10 {
+		t.Errorf("%d goroutines after %d busy refusals, up from %d", n, busy, goroutines)
+	}
+	if h := r.Health(); h.Busy != uint32(busy) || h.DroppedReports != uint32(busy) {
+		t.Errorf("Health Busy = %d, DroppedReports = %d, want %d each", h.Busy, h.DroppedReports, busy)
+	}
+
+	go func() {
+		for range reports {
+		}
+	}()
+	close(release)
+	waitFor(t, "On", r.Get)
+}
@@ -0,0 +1,54 @@
+package core
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrBadName is returned when a Relay is given an empty name or one containing spaces
+var ErrBadName = errors.New("relay: bad name")
+
+// SetName renames the Relay, e.g. when a channel is relabelled in the field, so firmware needn't
+// change: the Banks it belongs to file it under the new name, a stored fault history moves to the
+// new name's key, WatchName's callbacks are told – e.g. to re-register it with a Dispatcher – and
+// the Relay announces itself under the new name on its report fallback for discovery.
+func (r *relay) SetName(name string) error {
+	var err error
+	r.do(func() { err = r.rename(name) })
+	return err
+}
+
+// WatchName registers f to be called with the Relay's old and new names whenever it is renamed.
+// f is called from the Relay's worker, so it must not block or call the Relay's synchronous methods.
+func (r *relay) WatchName(f func(old, name string)) {
+	r.mu.Lock()
+	r.nameWatchers = append(r.nameWatchers, f)
+	r.mu.Unlock()
+}
+
+// rename renames the Relay; it is only ever called from the worker
+func (r *relay) rename(name string) error {
+	if name == "" || strings.ContainsAny(name, " \t\r\n") {
+		return ErrBadName
+	}
+	old := r.Name()
+	if name == old {
+		return nil
+	}
+	r.name.Store(name)
+	r.mu.Lock()
+	s, watchers := r.faultStore, r.nameWatchers
+	r.mu.Unlock()
+	if s != nil {
+		if b, err := s.Load(faultKey(old)); err == nil && len(b) > 0 {
+			if s.Save(faultKey(name), b) == nil {
+				s.Save(faultKey(old), nil)
+			}
+		}
+	}
+	for _, f := range watchers {
+		f(old, name)
+	}
+	r.announce(Trigger{Target: name, Action: ActionAnnounce, Source: SourceInternal})
+	return nil
+}
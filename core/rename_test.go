@@ -0,0 +1,34 @@
+package core_test
+
+import (
+	"testing"
+
+	"github.com/eyelight/relay/core"
+	"github.com/eyelight/relay/relaytest"
+)
+
+func TestRenameWhileInUse(t *testing.T) {
+	r := core.New(relaytest.NewOutput(), "pump")
+	r.Configure()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, name := range []string{"pump1", "pump2", "pump3", "well"} {
+			if err := r.SetName(name); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+			r.Execute(core.Trigger{Target: r.Name(), Action: core.ActionOff, Source: core.SourceInternal})
+			r.Config()
+		}
+	}
+	if r.Name() != "well" {
+		t.Errorf("Name() = %q, want well", r.Name())
+	}
+}
@@ -0,0 +1,246 @@
+package core
+
+import (
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ReportKind says what a Report is about
+type ReportKind uint8
+
+const (
+	ReportInfo      ReportKind = iota // Detail says it all, e.g. a deferral or a queued command
+	ReportOn                          // the load switched on, for Duration (0 is indefinitely)
+	ReportOff                         // the load switched off after Elapsed
+	ReportForcedOff                   // a run was ended early, for Cause, after Elapsed
+	ReportDuration                    // a run's duration changed from Previous to Duration, Elapsed into it
+	ReportFault                       // a Fault switched the load off after Elapsed; Detail has the particulars
+	ReportSetting                     // Setting was changed to Duration
+	ReportRefused                     // a command was understood but refused; Detail says why
+	ReportInvalid                     // a command could not be understood; Detail says why
+	ReportDryRun                      // the outcome of a dry run, in Detail
+	ReportAnnounce                    // the Relay is online: On or Off, with Duration left of a resumed run, and ConfigHash
+	ReportRenamed                     // the Relay was renamed from Detail
+	ReportProgress                    // progress of the Scheduler or a group (a Cascade, Scene, Session or zone walk), in Detail, at Time
+)
+
+// Report is the structured form of everything a Relay reports. The Relay turns it into the
+// Trigger's Message with its Formatter.
+type Report struct {
+	Relay      string
+	Action     Action // the Trigger's Action
+	Kind       ReportKind
+	Error      bool
+	Seq        uint32 // sequence number of the latest history Entry the Report reflects; 0 for refusals
+	Cause      Cause
+	Source     Source
+	Fault      Fault
+	Setting    string
+	Duration   time.Duration
+	Previous   time.Duration
+	Elapsed    time.Duration
+	Time       time.Time
+	Detail     string
+	On         bool   // for ReportAnnounce, the state of the load
+	Resumed    bool   // for ReportAnnounce, a run was resumed
+	ConfigHash uint32 // for ReportAnnounce, see RelayConfig.Hash
+}
+
+// Formatter renders a Report as the human- (or machine-) readable text of a Trigger's Message
+type Formatter func(Report) string
+
+// DefaultFormatter renders Reports in the package's usual English, e.g.
+// "Pump - On for 5m0s at 02 Jan 06 15:04 MST (seq 12, command)"
+func DefaultFormatter(rep Report) string {
+	ss := strings.Builder{}
+	ss.Grow(128)
+	if rep.Error && rep.Kind != ReportFault {
+		ss.WriteString("error - ")
+		ss.WriteString(rep.Relay)
+		ss.WriteString(" ")
+	} else {
+		ss.WriteString(rep.Relay)
+		ss.WriteString(" - ")
+	}
+	at := " at " + stamp(rep.Time)
+	switch rep.Kind {
+	case ReportOn:
+		if rep.Duration <= 0 {
+			ss.WriteString("On indefinitely" + at)
+		} else {
+			ss.WriteString("On for " + rep.Duration.String() + at)
+		}
+	case ReportOff:
+		ss.WriteString("Off after " + rep.Elapsed.String() + at)
+	case ReportForcedOff:
+		ss.WriteString("Forced Off (" + rep.Cause.String() + ") after " + rep.Elapsed.String() + at)
+	case ReportDuration:
+		ss.WriteString("Changing On duration to " + rep.Duration.String() + " (after " + rep.Elapsed.String() + " of a scheduled " + rep.Previous.String() + ")" + at)
+	case ReportFault:
+		ss.WriteString(rep.Fault.String() + " fault: " + rep.Detail + ", Off after " + rep.Elapsed.String() + at)
+	case ReportRenamed:
+		ss.WriteString("renamed from " + rep.Detail + at)
+	case ReportProgress:
+		ss.WriteString(rep.Detail + at)
+	case ReportSetting:
+		ss.WriteString(strings.TrimPrefix(rep.Setting, "Set") + " set to " + rep.Duration.String() + at)
+	case ReportAnnounce:
+		switch {
+		case rep.Resumed:
+			ss.WriteString("online, resumed On for " + durationString(rep.Duration))
+		case rep.On:
+			ss.WriteString("online, On")
+		default:
+			ss.WriteString("online, Off")
+		}
+		ss.WriteString(", config " + strconv.FormatUint(uint64(rep.ConfigHash), 16) + at)
+	default:
+		ss.WriteString(rep.Detail)
+	}
+	if rep.Seq > 0 {
+		ss.WriteString(" (seq ")
+		ss.WriteString(strconv.FormatUint(uint64(rep.Seq), 10))
+		ss.WriteString(", ")
+		ss.WriteString(rep.Cause.String())
+		if rep.Cause == CauseCommand || rep.Cause == CauseSchedule {
+			ss.WriteString(" from ")
+			ss.WriteString(rep.Source.String())
+		}
+		ss.WriteString(")")
+	}
+	return ss.String()
+}
+
+// SetFormatter replaces the text of the Relay's reports, e.g. with terse machine-friendly strings
+// or a translation; nil restores DefaultFormatter
+func (r *relay) SetFormatter(f Formatter) {
+	r.mu.Lock()
+	r.formatter = f
+	r.mu.Unlock()
+}
+
+// format renders rep with the Relay's Formatter
+func (r *relay) format(rep Report) string {
+	r.mu.Lock()
+	f := r.formatter
+	r.mu.Unlock()
+	if f == nil {
+		f = DefaultFormatter
+	}
+	return f(rep)
+}
+
+// formatFor renders rep, a report from the Scheduler or a group rather than a Relay, with the
+// Formatter of about – the Relay it concerns, e.g. the one a step switches – or DefaultFormatter if
+// there is none
+func formatFor(about Relay, rep Report) string {
+	if r, ok := about.(*relay); ok {
+		return r.format(rep)
+	}
+	return DefaultFormatter(rep)
+}
+
+// post stamps rep and sends it to reportCh as formatFor renders it, or prints it if reportCh is nil
+func post(reportCh chan Trigger, about Relay, rep Report) {
+	if rep.Time.IsZero() {
+		rep.Time = now()
+	}
+	reply(Trigger{
+		Target:   rep.Relay,
+		Action:   rep.Action,
+		Error:    rep.Error,
+		Severity: rep.Severity(),
+		Message:  formatFor(about, rep),
+		ReportCh: reportCh,
+	})
+}
+
+// send completes rep from t and sends t back to its sender with the formatted Message
+func (r *relay) send(t Trigger, rep Report) {
+	r.deliver(t, rep, true)
+}
+
+// deliver is send, but when wait is false a report the ReportCh has no room for is dropped and
+// counted rather than waited on
+func (r *relay) deliver(t Trigger, rep Report, wait bool) {
+	rep.Relay = r.Name()
+	rep.Action = t.Action
+	if rep.Time.IsZero() {
+		rep.Time = now()
+	}
+	t.Error = rep.Error
+	t.Severity = rep.Severity()
+	t.Message = r.format(rep)
+	if t.ReportCh == nil {
+		r.mu.Lock()
+		t.ReportCh = r.fallback
+		r.mu.Unlock()
+	}
+	if t.ReportCh == nil {
+		println(t.Message)
+		return
+	}
+	defer func() {
+		if recover() != nil { // the sender closed its ReportCh
+			atomic.AddUint32(&r.droppedReports, 1)
+			println(t.Message)
+		}
+	}()
+	if wait {
+		t.ReportCh <- t
+		return
+	}
+	select {
+	case t.ReportCh <- t:
+	default:
+		atomic.AddUint32(&r.droppedReports, 1)
+	}
+}
+
+// SetReportFallback sets where the Relay's reports go when a Trigger carries no ReportCh, e.g.
+// one built by a program that doesn't care for replies: to ch, or printed to the console if ch is
+// nil (the default). Reports to a ReportCh that has been closed are printed too.
+func (r *relay) SetReportFallback(ch chan Trigger) {
+	r.mu.Lock()
+	r.fallback = ch
+	r.mu.Unlock()
+}
+
+// report sends rep about t, stamped with the sequence number and cause of the latest Entry it reflects
+func (r *relay) report(t Trigger, rep Report) {
+	r.mu.Lock()
+	rep.Seq = r.seq
+	if r.seq > 0 {
+		rep.Cause = r.history[(r.seq-1)%uint32(len(r.history))].Cause
+		rep.Source = r.history[(r.seq-1)%uint32(len(r.history))].Source
+	}
+	r.mu.Unlock()
+	r.send(t, rep)
+}
+
+// reject sends t back to its sender as a refused command; refusals are not numbered
+func (r *relay) reject(t Trigger, detail string) {
+	r.mu.Lock()
+	r.commands.Rejected++
+	r.mu.Unlock()
+	r.send(t, Report{Kind: ReportRefused, Error: true, Detail: detail})
+}
+
+// rejectNow is reject for callers that mustn't block, such as Offer, whose caller may be the one to
+// read the report: a report its ReportCh has no room for is dropped and counted
+func (r *relay) rejectNow(t Trigger, detail string) {
+	r.mu.Lock()
+	r.commands.Rejected++
+	r.mu.Unlock()
+	r.deliver(t, Report{Kind: ReportRefused, Error: true, Detail: detail}, false)
+}
+
+// fail sends t back to its sender as a command that could not be understood
+func (r *relay) fail(t Trigger, detail string) {
+	r.mu.Lock()
+	r.commands.Errored++
+	r.mu.Unlock()
+	r.send(t, Report{Kind: ReportInvalid, Error: true, Detail: detail})
+}
@@ -0,0 +1,57 @@
+package core
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// SetRepulse refreshes the drive of a Relay that is on every interval, for optocoupler boards with
+// weak drive whose channels drop out unless the signal is re-asserted. Before each refresh the
+// Output is read back; if it has dropped out, that is counted in Health and reported to the report
+// fallback. 0 stops refreshing.
+func (r *relay) SetRepulse(interval time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.repulseStop != nil {
+		close(r.repulseStop)
+		r.repulseStop = nil
+	}
+	r.repulseEvery = interval
+	if interval > 0 {
+		r.repulseStop = make(chan struct{})
+		go r.repulse(interval, r.repulseStop)
+	}
+}
+
+// repulse refreshes the drive of the Relay while it is on, every interval until stop is closed
+func (r *relay) repulse(interval time.Duration, stop chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			r.do(func() {
+				if len(r.offCh) > 0 { // an Off has cut the output and awaits the worker
+					return
+				}
+				// under wmu, so a run ending or a cut on another goroutine can't come between the
+				// check and the Set and be undone
+				r.wmu.Lock()
+				if !r.recordedOn() || r.barred() != "" {
+					r.wmu.Unlock()
+					return
+				}
+				dropped := r.out.Get() != r.level(true)
+				r.out.Set(r.level(true))
+				r.wmu.Unlock()
+				if dropped {
+					atomic.AddUint32(&r.dropOuts, 1)
+					r.send(Trigger{Target: r.Name(), Action: "Repulse"}, Report{Kind: ReportInfo, Error: true,
+						Detail: "dropped out while On, re-asserted after up to " + interval.String()})
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package core
+
+import (
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// resetPoke is how long ForceReset waits for an idle worker to take its wake-up call
+const resetPoke = 10 * time.Millisecond
+
+// ForceReset recovers a Relay whose machinery has wedged – e.g. a worker stuck sending a report
+// nobody reads – without a reboot. It drives the output to its off level, abandons the worker and
+// any run (they exit if they ever come unstuck), drops queued Triggers and returns the Relay to
+// idle under a fresh worker, then reports the recovery to the Relay's report fallback (see
+// SetReportFallback). A latched Fault and the counters are kept.
+func (r *relay) ForceReset() {
+	r.cut()
+	gen := atomic.AddUint32(&r.gen, 1)
+	select {
+	case r.ctl <- func() {}: // an idle worker wakes, sees it has been replaced and exits
+	case <-time.After(resetPoke):
+	}
+	r.mu.Lock()
+	abandoned := r.run
+	r.run = nil
+	r.mu.Unlock()
+	if abandoned != nil {
+		select {
+		case abandoned.off <- CauseReset:
+		default:
+		}
+	}
+	dropped := 0
+	for drained := false; !drained; {
+		select {
+		case <-r.offCh:
+			dropped++
+		case <-r.cmd:
+			dropped++
+		default:
+			drained = true
+		}
+	}
+	r.write(false, CauseReset)
+	r.reset()
+	go r.work(gen)
+	r.report(Trigger{Target: r.Name(), Action: "ForceReset"}, Report{
+		Kind:   ReportInfo,
+		Detail: "recovered by ForceReset, Off and idle; " + strconv.Itoa(dropped) + " queued Triggers dropped",
+	})
+}
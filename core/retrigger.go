@@ -0,0 +1,31 @@
+package core
+
+import "time"
+
+// SetRetrigger makes the Relay a retriggerable monostable, like a stairwell light on a motion
+// sensor: each timed "On" received during a run restarts the countdown from now, rather than
+// revising the run's duration as measured from when it started. MaxOn still caps the whole run.
+func (r *relay) SetRetrigger(on bool) {
+	r.mu.Lock()
+	r.retrigger = on
+	r.mu.Unlock()
+}
+
+// retriggers returns whether the Relay is set as a retriggerable monostable
+func (r *relay) retriggers() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.retrigger
+}
+
+// retriggered returns the run duration that ends d from now
+func (r *relay) retriggered(d time.Duration) time.Duration {
+	total := r.elapsed() + d
+	r.mu.Lock()
+	maxOn := r.maxOn
+	r.mu.Unlock()
+	if maxOn > 0 && total > maxOn {
+		total = maxOn
+	}
+	return total
+}
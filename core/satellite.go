@@ -0,0 +1,53 @@
+package core
+
+import (
+	"strconv"
+	"time"
+)
+
+// Satellite is a relay board reached over I2C through its own Expander, one of several a main
+// controller may address. Its Relays are named "<board>/relay<n>", so a Dispatcher routes Triggers
+// such as Target "boardA/relay3" to the right board.
+type Satellite struct {
+	*Bank
+	exp *Expander
+}
+
+// SatelliteHealth is the outcome of a Satellite's health check
+type SatelliteHealth struct {
+	Board    string
+	Addr     uint16
+	Online   bool      // the board answered the check
+	Err      error     // why it didn't
+	LastSeen time.Time // when it last answered
+}
+
+// NewSatellite returns a Satellite named board with channels relays (up to 8) on the Expander at
+// addr on bus. Most such boards switch a relay on with a low pin, so set activeLow for them.
+func NewSatellite(board string, bus I2C, addr uint16, channels int, activeLow bool) *Satellite {
+	exp := NewExpander(bus, addr)
+	if channels > 8 {
+		channels = 8
+	}
+	relays := make([]Relay, channels)
+	for i := range relays {
+		relays[i] = New(exp.Output(uint8(i)), board+"/relay"+strconv.Itoa(i+1))
+		relays[i].SetActiveLow(activeLow)
+	}
+	return &Satellite{
+		Bank: NewBank(board, relays...),
+		exp:  exp,
+	}
+}
+
+// Health pings the board and reports whether it answered
+func (s *Satellite) Health() SatelliteHealth {
+	err := s.exp.Ping()
+	return SatelliteHealth{
+		Board:    s.Name(),
+		Addr:     s.exp.addr,
+		Online:   err == nil,
+		Err:      err,
+		LastSeen: s.exp.LastSeen(),
+	}
+}
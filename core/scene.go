@@ -0,0 +1,158 @@
+package core
+
+import (
+	"strconv"
+	"time"
+)
+
+// sceneSettle is how long a Scene waits by default for a Step to verify
+const sceneSettle = 200 * time.Millisecond
+
+// SceneFailure says what a Scene does when a Step fails verification
+type SceneFailure uint8
+
+const (
+	SceneStop     SceneFailure = iota // leave the Steps already taken as they are
+	SceneRollback                     // return the Relays of the Steps taken, the failed one included, to how they were, last first
+)
+
+// Step is one switching in a Scene
+type Step struct {
+	Relay    Relay
+	On       bool
+	Duration time.Duration      // of an On Step; 0 gives the Relay's default
+	Settle   time.Duration      // how long the Step has to verify; 0 gives 200ms
+	Verify   func(r Relay) bool // whether r reached its target, e.g. from a feedback contact; nil compares Load with On
+}
+
+// Scene is a sequence of Steps applied in order, each verified before the next is taken, e.g. an
+// interlocked start-up where a pump must be running before its heater may switch on
+type Scene struct {
+	Name      string
+	Steps     []Step
+	OnFailure SceneFailure
+}
+
+// SceneError describes the Step at which a Scene was abandoned
+type SceneError struct {
+	Scene  string
+	Step   int // counting from 1
+	Relay  string
+	Detail string
+}
+
+// Error describes the failed Step
+func (e *SceneError) Error() string {
+	return "relay: scene " + e.Scene + " failed at step " + strconv.Itoa(e.Step) + " (" + e.Relay + "): " + e.Detail
+}
+
+// Apply takes the Scene's Steps in order, waiting for each to verify before the next. If one doesn't,
+// the Scene is abandoned – and rolled back, if so set – and a *SceneError returned. Progress and the
+// outcome are reported to reportCh. Apply blocks until the Scene is over.
+func (sc Scene) Apply(reportCh chan Trigger) error {
+	type before struct {
+		on   bool
+		left time.Duration
+	}
+	was := make([]before, 0, len(sc.Steps))
+	n := strconv.Itoa(len(sc.Steps))
+	for i, s := range sc.Steps {
+		left, _ := s.Relay.Remaining()
+		was = append(was, before{on: s.Relay.Get(), left: left})
+		s.Relay.Execute(s.trigger(reportCh))
+		if s.verified() {
+			post(reportCh, s.Relay, Report{
+				Relay:  sc.Name,
+				Action: "Scene",
+				Kind:   ReportProgress,
+				Detail: "scene step " + strconv.Itoa(i+1) + "/" + n + ": " + s.Relay.Name() + " " + stateName(s.On) + ", verified",
+			})
+			continue
+		}
+		err := &SceneError{
+			Scene:  sc.Name,
+			Step:   i + 1,
+			Relay:  s.Relay.Name(),
+			Detail: "did not reach " + stateName(s.On) + " within " + s.settle().String(),
+		}
+		if sc.OnFailure == SceneRollback {
+			for j := i; j >= 0; j-- {
+				sc.Steps[j].Relay.Execute(restore(sc.Steps[j].Relay, was[j].on, was[j].left, reportCh))
+			}
+			err.Detail += ", rolled back"
+		}
+		post(reportCh, s.Relay, Report{
+			Relay:  sc.Name,
+			Action: "Scene",
+			Kind:   ReportProgress,
+			Error:  true,
+			Detail: "scene failed at step " + strconv.Itoa(i+1) + "/" + n + " (" + err.Relay + "): " + err.Detail,
+		})
+		return err
+	}
+	post(reportCh, nil, Report{
+		Relay:  sc.Name,
+		Action: "Scene",
+		Kind:   ReportProgress,
+		Detail: "scene applied, " + n + " steps",
+	})
+	return nil
+}
+
+// trigger is the Trigger that takes the Step
+func (s Step) trigger(reportCh chan Trigger) Trigger {
+	t := Trigger{Target: s.Relay.Name(), Action: ActionOff, ReportCh: reportCh, Source: SourceInternal}
+	if s.On {
+		t.Action = ActionOn
+		t.Duration = s.Duration
+	}
+	return t
+}
+
+// settle returns how long the Step has to verify
+func (s Step) settle() time.Duration {
+	if s.Settle <= 0 {
+		return sceneSettle
+	}
+	return s.Settle
+}
+
+// verified waits up to the Step's settle time for its Relay to reach its target
+func (s Step) verified() bool {
+	verify := s.Verify
+	if verify == nil {
+		verify = func(r Relay) bool { return r.Load() == s.On }
+	}
+	deadline := now().Add(s.settle())
+	for {
+		if verify(s.Relay) {
+			return true
+		}
+		if now().After(deadline) {
+			return false
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// restore is the Trigger that returns r to how it was before a Scene: on, for what was left of its
+// run (or indefinitely), or off
+func restore(r Relay, on bool, left time.Duration, reportCh chan Trigger) Trigger {
+	t := Trigger{Target: r.Name(), Action: ActionOff, ReportCh: reportCh, Source: SourceInternal}
+	if on {
+		t.Action = ActionOn + " " + ActionIndefinitely
+		if left > 0 {
+			t.Action = ActionOn
+			t.Duration = left
+		}
+	}
+	return t
+}
+
+// stateName names an on/off target for reports
+func stateName(on bool) string {
+	if on {
+		return string(ActionOn)
+	}
+	return string(ActionOff)
+}
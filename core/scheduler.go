@@ -0,0 +1,466 @@
+package core
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// schedulerRecheck caps how long a Scheduler sleeps before checking the wall clock again
+	schedulerRecheck = time.Minute
+	// clockJump is how far the wall clock may be set, e.g. corrected by NTP, and still be followed
+	// firing by firing; a bigger jump, such as setting it from an RTC after boot, makes a Scheduler
+	// replan from the new time
+	clockJump = time.Hour
+)
+
+// Weekdays is a set of days of the week; the zero value means every day
+type Weekdays uint8
+
+// Days returns the set of the given days
+func Days(days ...time.Weekday) Weekdays {
+	var w Weekdays
+	for _, d := range days {
+		w |= 1 << uint(d)
+	}
+	return w
+}
+
+// has reports whether d is in the set
+func (w Weekdays) has(d time.Weekday) bool {
+	return w == 0 || w&(1<<uint(d)) != 0
+}
+
+// Schedule switches a Relay at a time of day, on some or all days of the week
+type Schedule struct {
+	Name      string // identifies the Schedule to Remove and in reports
+	Relay     Relay
+	At        time.Duration // time of day, local, since midnight, e.g. 6*time.Hour + 30*time.Minute
+	Days      Weekdays      // days the Schedule fires on; 0 for every day
+	Action    Action        // ActionOn or ActionOff
+	Duration  time.Duration // for "On", how long to run; 0 is indefinitely
+	Tags      []string      // groups Schedules for SuspendSchedules, e.g. "irrigation"
+	AvoidPeak bool          // an "On" falling in the Tariff's peak is deferred to the end of the peak
+
+	// If, when set, is asked at each firing whether to go ahead, e.g. only if soil moisture is
+	// under 30%; when it says no, the firing passes without switching and why is reported
+	If func() (ok bool, why string)
+
+	skip time.Time // a firing to let pass without switching
+}
+
+// tagged reports whether the Schedule carries any of tags; no tags matches every Schedule
+func (s *Schedule) tagged(tags []string) bool {
+	if len(tags) == 0 {
+		return true
+	}
+	for _, want := range tags {
+		for _, have := range s.Tags {
+			if have == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// next returns the Schedule's first firing after t
+func (s *Schedule) next(t time.Time) time.Time {
+	t = t.Local()
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+	for i := 0; i < 8; i++ {
+		day := midnight.AddDate(0, 0, i)
+		at := day.Add(s.At)
+		if at.After(t) && s.Days.has(day.Weekday()) {
+			return at
+		}
+	}
+	return time.Time{}
+}
+
+// ScheduledEvent is an upcoming transition planned by a Scheduler
+type ScheduledEvent struct {
+	Time      time.Time
+	Relay     string
+	Action    Action
+	Duration  time.Duration // for "On", how long the run will last; 0 is indefinitely
+	Schedule  string
+	Skipped   bool // the firing will pass without switching
+	Suspended bool // the firing falls within a suspension and will pass without switching
+}
+
+// suspension pauses the Schedules carrying any of tags (all of them if none) until a time
+type suspension struct {
+	until time.Time
+	tags  []string
+}
+
+// Scheduler fires Schedules, sending the resulting Triggers' reports to its report channel
+type Scheduler struct {
+	mu          sync.Mutex // guards schedules & suspensions
+	schedules   []*Schedule
+	suspensions []*suspension
+	reportCh    chan Trigger
+	wake        chan struct{}
+}
+
+// NewScheduler returns an empty Scheduler whose Triggers report on reportCh
+func NewScheduler(reportCh chan Trigger) *Scheduler {
+	return &Scheduler{
+		reportCh: reportCh,
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Add adds s to the Scheduler, replacing any Schedule of the same name
+func (sc *Scheduler) Add(s Schedule) {
+	sc.mu.Lock()
+	sc.remove(s.Name)
+	sc.schedules = append(sc.schedules, &s)
+	sc.mu.Unlock()
+	sc.poke()
+}
+
+// Remove removes the Schedule called name
+func (sc *Scheduler) Remove(name string) {
+	sc.mu.Lock()
+	sc.remove(name)
+	sc.mu.Unlock()
+	sc.poke()
+}
+
+// remove must be called with sc.mu held
+func (sc *Scheduler) remove(name string) {
+	for i, s := range sc.schedules {
+		if s.Name == name {
+			sc.schedules = append(sc.schedules[:i], sc.schedules[i+1:]...)
+			return
+		}
+	}
+}
+
+// poke makes Run recompute its next firing
+func (sc *Scheduler) poke() {
+	select {
+	case sc.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run fires Schedules as they fall due, for as long as the program runs
+func (sc *Scheduler) Run() {
+	last := time.Now()
+	for {
+		due, when := sc.due(last)
+		wait := schedulerRecheck
+		if !when.IsZero() && time.Until(when) < wait {
+			wait = time.Until(when)
+		}
+		start := time.Now()
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+			now := time.Now()
+			last = sc.tick(last, due, when, now, skew(start, now))
+		case <-sc.wake:
+			timer.Stop()
+		}
+	}
+}
+
+// tick fires the Schedules due at when if that time has come, returning the time firings have been
+// made up to. now is the wall time on waking, and skew how far the wall clock was set while asleep.
+// A clock set by up to clockJump is simply followed: firings it steps over going forward are made
+// as usual, and since last never moves back, setting it back doesn't repeat any. A bigger jump
+// replans from now: firings it skipped over are dropped rather than run all at once, and those it
+// moved into the future wait for their time.
+func (sc *Scheduler) tick(last time.Time, due []*Schedule, when, now time.Time, skew time.Duration) time.Time {
+	if skew > clockJump || skew < -clockJump {
+		println("relay.Scheduler: the clock was set, replanning from " + now.Local().Format(time.RFC822))
+		return now
+	}
+	if when.IsZero() || now.Before(when) {
+		return last // a recheck
+	}
+	for _, s := range due {
+		sc.fire(s, when)
+	}
+	return when
+}
+
+// skew returns how far the wall clock was set between start and now – e.g. by NTP, or from an RTC
+// after boot – as the wall time elapsed less the monotonic time elapsed
+func skew(start, now time.Time) time.Duration {
+	return now.Round(0).Sub(start.Round(0)) - now.Sub(start)
+}
+
+// due returns the Schedules firing soonest after t, and when
+func (sc *Scheduler) due(t time.Time) ([]*Schedule, time.Time) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	var due []*Schedule
+	var when time.Time
+	for _, s := range sc.schedules {
+		next := s.next(t)
+		switch {
+		case next.IsZero():
+		case when.IsZero() || next.Before(when):
+			due, when = []*Schedule{s}, next
+		case next.Equal(when):
+			due = append(due, s)
+		}
+	}
+	return due, when
+}
+
+// fire executes a Schedule's Trigger due at when, unless that firing is to be skipped
+func (sc *Scheduler) fire(s *Schedule, when time.Time) {
+	sc.mu.Lock()
+	skipped := s.skip.Equal(when)
+	if !s.skip.IsZero() && !s.skip.After(when) {
+		s.skip = time.Time{} // used, or stale
+	}
+	suspended := sc.suspended(s, when)
+	sc.mu.Unlock()
+	why := ""
+	switch {
+	case skipped:
+		why = "skipped"
+	case suspended:
+		why = "suspended"
+	case s.If != nil:
+		if ok, reason := s.If(); !ok {
+			why = "skipped (" + reason + ")"
+		}
+	}
+	if why != "" {
+		post(sc.reportCh, s.Relay, Report{
+			Relay:  s.Relay.Name(),
+			Action: s.Action,
+			Kind:   ReportProgress,
+			Detail: "scheduled " + string(s.Action) + " (" + s.Name + ") " + why,
+			Time:   when,
+		})
+		return
+	}
+	t := Trigger{
+		Target:   s.Relay.Name(),
+		Action:   s.Action,
+		Duration: s.Duration,
+		ReportCh: sc.reportCh,
+		Source:   SourceSchedule,
+	}
+	if b, left := currentTariff().band(time.Now()); s.AvoidPeak && isOn(s.Action) && b == BandPeak {
+		post(sc.reportCh, s.Relay, Report{
+			Relay:  s.Relay.Name(),
+			Action: s.Action,
+			Kind:   ReportProgress,
+			Detail: "scheduled " + string(s.Action) + " (" + s.Name + ") deferred by " + left.String() + " to the end of the peak tariff",
+		})
+		time.AfterFunc(left, func() { s.Relay.Execute(t) })
+		return
+	}
+	s.Relay.Execute(t)
+}
+
+// NextEvents returns the next n transitions the Scheduler will cause, across all its Schedules,
+// soonest first. A timed "On" contributes both its On and the Off ending it.
+func (sc *Scheduler) NextEvents(n int) []ScheduledEvent {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	now := time.Now()
+	var events []ScheduledEvent
+	for _, s := range sc.schedules {
+		t := now
+		for i := 0; i < n; i++ {
+			at := s.next(t)
+			if at.IsZero() {
+				break
+			}
+			skipped := at.Equal(s.skip)
+			suspended := sc.suspended(s, at)
+			events = append(events, ScheduledEvent{
+				Time:      at,
+				Relay:     s.Relay.Name(),
+				Action:    s.Action,
+				Duration:  s.Duration,
+				Schedule:  s.Name,
+				Skipped:   skipped,
+				Suspended: suspended,
+			})
+			if isOn(s.Action) && s.Duration > 0 && !skipped && !suspended {
+				events = append(events, ScheduledEvent{
+					Time:     at.Add(s.Duration),
+					Relay:    s.Relay.Name(),
+					Action:   ActionOff,
+					Schedule: s.Name,
+				})
+			}
+			t = at
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Time.Before(events[j].Time)
+	})
+	if len(events) > n {
+		events = events[:n]
+	}
+	return events
+}
+
+// SkipNext lets the next firing of relay's Schedules pass without switching, e.g. to skip
+// tonight's irrigation because it rained. It returns the skipped event, and false if the
+// relay has nothing scheduled.
+func (sc *Scheduler) SkipNext(relay string) (ScheduledEvent, bool) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	now := time.Now()
+	var first *Schedule
+	var when time.Time
+	for _, s := range sc.schedules {
+		if s.Relay.Name() != relay {
+			continue
+		}
+		next := s.next(now)
+		for !next.IsZero() && next.Equal(s.skip) { // already skipped; look past it
+			next = s.next(next)
+		}
+		if !next.IsZero() && (when.IsZero() || next.Before(when)) {
+			first, when = s, next
+		}
+	}
+	if first == nil {
+		return ScheduledEvent{}, false
+	}
+	first.skip = when
+	return ScheduledEvent{
+		Time:     when,
+		Relay:    relay,
+		Action:   first.Action,
+		Duration: first.Duration,
+		Schedule: first.Name,
+		Skipped:  true,
+	}, true
+}
+
+// SchedulerName is the Trigger Target under which a Scheduler accepts commands
+const SchedulerName = "Scheduler"
+
+// Name returns SchedulerName and along with Execute lets a Scheduler be dispatched to like a Relay
+func (sc *Scheduler) Name() string {
+	return SchedulerName
+}
+
+// Execute accepts Scheduler commands: "Skip <relay>" skips the relay's next scheduled firing, and
+// "Suspend <duration> [tags...]" suspends Schedules as SuspendSchedules does
+func (sc *Scheduler) Execute(t Trigger) {
+	relay := strings.TrimPrefix(string(t.Action), "Skip ")
+	fields := strings.Fields(string(t.Action))
+	switch {
+	case t.Target != SchedulerName:
+		t.Error = true
+		t.Message = "error - " + SchedulerName + " received a trigger intended for " + t.Target
+	case len(fields) >= 2 && fields[0] == "Suspend":
+		d, err := time.ParseDuration(fields[1])
+		if err != nil || d <= 0 {
+			t.Error = true
+			t.Message = "error - " + SchedulerName + " could not parse Suspend duration '" + fields[1] + "'"
+			break
+		}
+		sc.SuspendSchedules(d, fields[2:]...)
+		return // SuspendSchedules reports
+	case relay == string(t.Action):
+		t.Error = true
+		t.Message = "error - " + SchedulerName + " does not understand Action: '" + string(t.Action) + "' (Skip <relay>, Suspend <duration> [tags...])"
+	default:
+		e, ok := sc.SkipNext(relay)
+		t.Error = !ok
+		if ok {
+			rep := Report{
+				Relay:  SchedulerName,
+				Action: t.Action,
+				Kind:   ReportProgress,
+				Detail: "skipping " + relay + "'s " + string(e.Action) + " (" + e.Schedule + ")",
+				Time:   e.Time,
+			}
+			t.Severity = rep.Severity()
+			t.Message = formatFor(nil, rep)
+		} else {
+			t.Message = "error - " + SchedulerName + " has nothing scheduled for " + relay
+		}
+	}
+	if t.ReportCh == nil {
+		println(t.Message)
+		return
+	}
+	t.ReportCh <- t
+}
+
+// SuspendSchedules lets the firings of all Schedules – or only those carrying any of tags – pass
+// without switching for d, e.g. a rain delay driven by a rain sensor or forecast, then resumes
+// them by itself. The start and end of the suspension are reported.
+func (sc *Scheduler) SuspendSchedules(d time.Duration, tags ...string) {
+	sp := &suspension{
+		until: time.Now().Add(d),
+		tags:  tags,
+	}
+	sc.mu.Lock()
+	sc.suspensions = append(sc.suspensions, sp)
+	sc.mu.Unlock()
+	sc.reportSuspension(sp, "suspended for "+d.String())
+	time.AfterFunc(d, func() {
+		if sc.lift(sp) {
+			sc.reportSuspension(sp, "resumed")
+		}
+	})
+}
+
+// ResumeSchedules lifts every suspension early
+func (sc *Scheduler) ResumeSchedules() {
+	sc.mu.Lock()
+	lifted := sc.suspensions
+	sc.suspensions = nil
+	sc.mu.Unlock()
+	for _, sp := range lifted {
+		sc.reportSuspension(sp, "resumed early")
+	}
+}
+
+// lift removes a suspension, returning false if it was already gone
+func (sc *Scheduler) lift(sp *suspension) bool {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	for i, s := range sc.suspensions {
+		if s == sp {
+			sc.suspensions = append(sc.suspensions[:i], sc.suspensions[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// suspended reports whether a firing of s at when falls within a suspension; sc.mu must be held
+func (sc *Scheduler) suspended(s *Schedule, when time.Time) bool {
+	for _, sp := range sc.suspensions {
+		if when.Before(sp.until) && s.tagged(sp.tags) {
+			return true
+		}
+	}
+	return false
+}
+
+// reportSuspension reports a change to a suspension
+func (sc *Scheduler) reportSuspension(sp *suspension, what string) {
+	which := "all schedules"
+	if len(sp.tags) > 0 {
+		which = "schedules tagged " + strings.Join(sp.tags, ", ")
+	}
+	post(sc.reportCh, nil, Report{
+		Relay:  SchedulerName,
+		Action: "Suspend",
+		Kind:   ReportProgress,
+		Detail: which + " " + what,
+	})
+}
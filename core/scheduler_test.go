@@ -0,0 +1,102 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+// wake is a Scheduler's timer going off: the wall time then, and how far the clock was set while
+// it slept
+type wake struct {
+	at   time.Time
+	skew time.Duration
+}
+
+// firings steps a Scheduler with Schedules at 07:00 and 07:30 through wakes as Run would, starting
+// from start, and returns how many times each Schedule fired
+func firings(t *testing.T, start time.Time, wakes ...wake) map[string]int {
+	t.Helper()
+	n := map[string]int{}
+	sc := NewScheduler(make(chan Trigger, 100))
+	lamp := NewVirtual("lamp", func() error { return nil }, func() error { return nil })
+	for _, at := range []time.Duration{7 * time.Hour, 7*time.Hour + 30*time.Minute} {
+		name := at.String()
+		sc.Add(Schedule{Name: name, Relay: lamp, At: at, Action: ActionOn, If: func() (bool, string) {
+			n[name]++
+			return false, "counted"
+		}})
+	}
+	last := start
+	for _, w := range wakes {
+		due, when := sc.due(last)
+		last = sc.tick(last, due, when, w.at, w.skew)
+	}
+	return n
+}
+
+func TestSchedulerClockSet(t *testing.T) {
+	day := func(h, m int) time.Time { return time.Date(2026, 3, 2, h, m, 0, 0, time.Local) }
+	for _, c := range []struct {
+		name  string
+		wakes []wake
+		want  map[string]int
+	}{
+		{"steady", []wake{
+			{day(7, 0), 0}, {day(7, 1), 0}, {day(7, 30), 0}, {day(7, 31), 0},
+		}, map[string]int{"7h0m0s": 1, "7h30m0s": 1}},
+		{"forward over a firing", []wake{ // the clock is set 40m on at 07:01, stepping over 07:30
+			{day(7, 0), 0}, {day(7, 41), 40 * time.Minute}, {day(7, 41), 0}, {day(7, 42), 0},
+		}, map[string]int{"7h0m0s": 1, "7h30m0s": 1}},
+		{"forward by seconds across a firing", []wake{
+			{day(7, 0).Add(5 * time.Second), 10 * time.Second}, {day(7, 30), 0},
+		}, map[string]int{"7h0m0s": 1, "7h30m0s": 1}},
+		{"back after a firing", []wake{ // fired 07:00, then the clock is set back 10m
+			{day(7, 0), 0}, {day(6, 51), -10 * time.Minute}, {day(6, 52), 0}, {day(7, 0), 0},
+			{day(7, 1), 0}, {day(7, 30), 0}, {day(7, 31), 0},
+		}, map[string]int{"7h0m0s": 1, "7h30m0s": 1}},
+		{"back across both firings", []wake{
+			{day(7, 0), 0}, {day(7, 30), 0}, {day(6, 59), -32 * time.Minute}, {day(7, 0), 0},
+			{day(7, 30), 0}, {day(7, 31), 0},
+		}, map[string]int{"7h0m0s": 1, "7h30m0s": 1}},
+		{"set from an RTC", []wake{ // the board booted at 1 Jan 2000; the RTC jumps it to 07:20
+			{day(7, 20), day(7, 20).Sub(time.Date(2000, 1, 1, 0, 1, 0, 0, time.Local))},
+			{day(7, 21), 0}, {day(7, 30), 0},
+		}, map[string]int{"7h30m0s": 1}},
+	} {
+		start := c.wakes[0].at.Add(-time.Minute - c.wakes[0].skew)
+		if got := firings(t, start, c.wakes...); !equalCounts(got, c.want) {
+			t.Errorf("%s: fired %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func equalCounts(a, b map[string]int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if b[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSkipNextWithNoFiring(t *testing.T) {
+	sc := NewScheduler(make(chan Trigger, 10))
+	lamp := NewVirtual("lamp", func() error { return nil }, func() error { return nil })
+	sc.Add(Schedule{Name: "never", Relay: lamp, At: 7 * time.Hour, Days: Weekdays(0x80), Action: ActionOn})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if _, ok := sc.SkipNext("lamp"); ok {
+			t.Error("SkipNext skipped a Schedule that never fires")
+		}
+		sc.Execute(Trigger{Target: SchedulerName, Action: "Skip lamp"}) // no ReportCh
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("SkipNext or Execute hung")
+	}
+}
@@ -0,0 +1,156 @@
+package core
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Session is a recorded sequence of timed commands, e.g. a watering round taught by hand, to be
+// replayed with Replay or repeated by a Scheduler with AddSession
+type Session struct {
+	Name  string
+	Steps []SessionStep
+}
+
+// SessionStep is one command of a Session
+type SessionStep struct {
+	At       time.Duration // since the Session began
+	Relay    Relay
+	Action   Action        // ActionOn, "On indefinitely" or ActionOff
+	Duration time.Duration // for "On", how long to run; 0 is the Relay's default
+}
+
+// Recording captures the "On" and "Off" commands its Relays accept, other than internal ones,
+// until it is stopped
+type Recording struct {
+	mu     sync.Mutex
+	start  time.Time
+	relays []*relay
+	steps  []SessionStep
+}
+
+// Record starts recording the commands given to relays
+func Record(relays ...Relay) *Recording {
+	rec := &Recording{start: now()}
+	for _, rr := range relays {
+		r, ok := rr.(*relay)
+		if !ok {
+			continue
+		}
+		rec.relays = append(rec.relays, r)
+		r.mu.Lock()
+		r.recordings = append(r.recordings, rec)
+		r.mu.Unlock()
+	}
+	return rec
+}
+
+// Stop ends the Recording and returns what it captured as a Session called name
+func (rec *Recording) Stop(name string) Session {
+	for _, r := range rec.relays {
+		r.mu.Lock()
+		for i, other := range r.recordings {
+			if other == rec {
+				r.recordings = append(r.recordings[:i:i], r.recordings[i+1:]...)
+				break
+			}
+		}
+		r.mu.Unlock()
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return Session{Name: name, Steps: append([]SessionStep(nil), rec.steps...)}
+}
+
+// recordCommand adds an accepted "On" or "Off" command to the Relay's Recordings
+func (r *relay) recordCommand(t Trigger, a ParsedAction) {
+	if t.Source == SourceInternal {
+		return
+	}
+	r.mu.Lock()
+	recordings := r.recordings
+	r.mu.Unlock()
+	step := SessionStep{Relay: r, Action: ActionOff}
+	if a.Op == OpOn {
+		step.Action = ActionOn
+		step.Duration = a.Duration
+		if a.Indefinite {
+			step.Action = ActionOn + " " + ActionIndefinitely
+		}
+	}
+	for _, rec := range recordings {
+		rec.mu.Lock()
+		step.At = since(rec.start)
+		rec.steps = append(rec.steps, step)
+		rec.mu.Unlock()
+	}
+}
+
+// Replay plays the Session's commands back with their original timing, reporting to reportCh before
+// each step and when it ends; closing stop (which may be nil) ends the replay early, leaving the
+// Relays as they are. Replay blocks until the replay is over.
+func (s Session) Replay(reportCh chan Trigger, stop <-chan struct{}) {
+	n := strconv.Itoa(len(s.Steps))
+	start := now()
+	for i, step := range s.Steps {
+		t := time.NewTimer(step.At - since(start))
+		select {
+		case <-t.C:
+		case <-stop:
+			t.Stop()
+			post(reportCh, step.Relay, Report{
+				Relay:  s.Name,
+				Action: "Replay",
+				Kind:   ReportProgress,
+				Detail: "replay stopped before step " + strconv.Itoa(i+1) + "/" + n,
+			})
+			return
+		}
+		post(reportCh, step.Relay, Report{
+			Relay:  s.Name,
+			Action: "Replay",
+			Kind:   ReportProgress,
+			Detail: "replay step " + strconv.Itoa(i+1) + "/" + n + ": " + step.Relay.Name() + " " + string(step.Action),
+		})
+		step.Relay.Execute(Trigger{
+			Target:   step.Relay.Name(),
+			Action:   step.Action,
+			Duration: step.Duration,
+			ReportCh: reportCh,
+			Source:   SourceSchedule,
+		})
+	}
+	post(reportCh, nil, Report{
+		Relay:  s.Name,
+		Action: "Replay",
+		Kind:   ReportProgress,
+		Detail: "replay finished, " + n + " steps",
+	})
+}
+
+// AddSession repeats s at the time of day at, on days, by adding a Schedule for each of its steps,
+// named "<session>#<step>" and tagged with the Session's name for SuspendSchedules
+func (sc *Scheduler) AddSession(s Session, at time.Duration, days Weekdays) {
+	for i, step := range s.Steps {
+		when, d := at+step.At, days
+		for when >= 24*time.Hour { // the step falls on a following day
+			when -= 24 * time.Hour
+			d = d.next()
+		}
+		sc.Add(Schedule{
+			Name:     s.Name + "#" + strconv.Itoa(i+1),
+			Relay:    step.Relay,
+			At:       when,
+			Days:     d,
+			Action:   step.Action,
+			Duration: step.Duration,
+			Tags:     []string{s.Name},
+		})
+	}
+}
+
+// next returns the days following each of the set's days
+func (w Weekdays) next() Weekdays {
+	return (w<<1 | w>>6) & 0x7f
+}
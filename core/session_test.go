@@ -0,0 +1,40 @@
+package core_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eyelight/relay/core"
+	"github.com/eyelight/relay/relaytest"
+)
+
+func TestRecordingSkipsRefusedCommands(t *testing.T) {
+	r := core.New(relaytest.NewOutput(), "pump")
+	r.Configure()
+	rec := core.Record(r)
+	r.Trip(core.FaultOvercurrent, "test")
+	reports := relaytest.NewRecorder()
+	r.Execute(core.Trigger{Target: "pump", Action: "On 1m", Source: core.SourceRemote, ReportCh: reports.C()})
+	reports.Expect(t, "overcurrent", time.Second)
+	r.ClearFault()
+	r.Execute(core.Trigger{Target: "pump", Action: "On 2m", Source: core.SourceRemote, ReportCh: reports.C()})
+	waitFor(t, "On", r.Get)
+	s := rec.Stop("round")
+	if len(s.Steps) != 1 || s.Steps[0].Duration != 2*time.Minute {
+		t.Fatalf("recorded %+v, want only the accepted On 2m", s.Steps)
+	}
+}
+
+func TestReplayReportsThroughFormatter(t *testing.T) {
+	r := core.New(relaytest.NewOutput(), "pump")
+	r.Configure()
+	r.SetFormatter(core.CompactFormatter)
+	s := core.Session{Name: "round", Steps: []core.SessionStep{{Relay: r, Action: core.ActionOff}}}
+	reports := relaytest.NewRecorder()
+	s.Replay(reports.C(), nil)
+	step := reports.Expect(t, `msg="replay step 1/1: pump Off"`, time.Second)
+	if step.Target != "round" || step.Severity != core.SeverityInfo {
+		t.Errorf("step report %+v, want Target round at SeverityInfo", step)
+	}
+	reports.Expect(t, "round - replay finished, 1 steps at ", time.Second)
+}
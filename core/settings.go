@@ -0,0 +1,130 @@
+package core
+
+import "time"
+
+// Names of the settings that may be changed remotely through Trigger actions of the same name,
+// e.g. Action "SetMaxOn 30m", or Action "SetMaxOn" with the value in Trigger.Duration
+const (
+	SettingDefaultDuration = "SetDefaultDuration"
+	SettingMaxOn           = "SetMaxOn"
+	SettingDutyBudget      = "SetDutyBudget"
+	SettingName            = "SetName" // takes a name rather than a duration, e.g. "SetName PorchLight"
+)
+
+// dutyWindow is the period over which a duty budget applies
+const dutyWindow = 24 * time.Hour
+
+// SetDefaultDuration sets the duration of "On" Triggers that omit one; 0 leaves them indefinite
+func (r *relay) SetDefaultDuration(d time.Duration) {
+	r.mu.Lock()
+	r.defaultDuration = d
+	r.mu.Unlock()
+}
+
+// SetMaxOn caps the length of any run, including indefinite ones; 0 removes the cap
+func (r *relay) SetMaxOn(d time.Duration) {
+	r.mu.Lock()
+	r.maxOn = d
+	r.mu.Unlock()
+}
+
+// SetDutyBudget caps the Relay's total on-time in each 24 hour window; once it is spent, runs
+// are cut short and "On" Triggers refused until the window rolls over. 0 removes the budget.
+func (r *relay) SetDutyBudget(d time.Duration) {
+	r.mu.Lock()
+	r.dutyBudget = d
+	r.dutyStart = now()
+	r.dutyBase = r.stats().OnTime
+	r.mu.Unlock()
+}
+
+// AllowRemote permits the named settings (SettingMaxOn, ...) to be changed through Triggers.
+// No settings may be changed remotely until they are allowed.
+func (r *relay) AllowRemote(settings ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.remote == nil {
+		r.remote = make(map[string]bool, len(settings))
+	}
+	for _, s := range settings {
+		r.remote[s] = true
+	}
+}
+
+// dutyRemaining returns how much of the duty budget is left in the current window, and false if there is no budget
+func (r *relay) dutyRemaining() (time.Duration, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.dutyBudget <= 0 {
+		return 0, false
+	}
+	onTime := r.stats().OnTime
+	if since(r.dutyStart) >= dutyWindow {
+		r.dutyStart = now()
+		r.dutyBase = onTime
+	}
+	left := r.dutyBudget - (onTime - r.dutyBase)
+	if left < 0 {
+		left = 0
+	}
+	return left, true
+}
+
+// limit applies the default duration, min & max on-time and remaining duty budget to a requested
+// run duration; 0 means none was given and indefinite is kept unless a cap applies
+func (r *relay) limit(d time.Duration) time.Duration {
+	r.mu.Lock()
+	def, minOn, maxOn := r.defaultDuration, r.minOn, r.maxOn
+	r.mu.Unlock()
+	if d == 0 {
+		d = def
+	}
+	if d > 0 && d < minOn {
+		d = minOn
+	}
+	if maxOn > 0 && (d <= 0 || d > maxOn) {
+		d = maxOn
+	}
+	if left, ok := r.dutyRemaining(); ok && left > 0 && (d <= 0 || d > left) {
+		d = left
+	}
+	return d
+}
+
+// settingRefusal explains why a setting may not be changed through Triggers, or returns ""
+func (r *relay) settingRefusal(name string) string {
+	r.mu.Lock()
+	allowed := r.remote[name]
+	r.mu.Unlock()
+	if !allowed {
+		return "does not allow remote " + name
+	}
+	return ""
+}
+
+// handleSetting applies a remote settings Trigger
+func (r *relay) handleSetting(t Trigger, a ParsedAction) {
+	if refusal := r.settingRefusal(a.Setting); refusal != "" {
+		r.reject(t, refusal)
+		return
+	}
+	switch a.Setting {
+	case SettingDefaultDuration:
+		r.SetDefaultDuration(a.Duration)
+	case SettingMaxOn:
+		r.SetMaxOn(a.Duration)
+	case SettingDutyBudget:
+		r.SetDutyBudget(a.Duration)
+	case SettingName:
+		old := r.Name()
+		if err := r.rename(a.Name); err != nil {
+			r.fail(t, "could not rename itself '"+a.Name+"'")
+			return
+		}
+		r.audit(EntryCommand, a.Setting+" "+a.Name, CauseCommand)
+		r.report(t, Report{Kind: ReportRenamed, Detail: old})
+		return
+	}
+	r.audit(EntryCommand, a.Setting+" "+a.Duration.String(), CauseCommand)
+	r.report(t, Report{Kind: ReportSetting, Setting: a.Setting, Duration: a.Duration})
+}
@@ -0,0 +1,39 @@
+package core_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eyelight/relay/core"
+	"github.com/eyelight/relay/relaytest"
+)
+
+func TestSettingsWhileRunning(t *testing.T) {
+	r := core.New(relaytest.NewOutput(), "pump")
+	r.Configure()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			r.AllowRemote(core.SettingMaxOn, core.SettingDutyBudget)
+			r.SetMaxOn(time.Duration(i) * time.Minute)
+			r.SetDefaultDuration(time.Duration(i) * time.Second)
+			r.SetDutyBudget(time.Hour)
+			r.SetRetrigger(i%2 == 0)
+		}
+	}()
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+			r.Config()
+			r.Validate(core.Trigger{Target: "pump", Action: core.ActionOn})
+			r.Execute(core.Trigger{Target: "pump", Action: "SetMaxOn 1h", Source: core.SourceInternal})
+			r.Execute(core.Trigger{Target: "pump", Action: core.ActionOn, Duration: time.Second, Source: core.SourceInternal})
+		}
+	}
+	if c := r.Config(); c.MaxOn == 0 || len(c.Remote) != 2 {
+		t.Errorf("Config MaxOn = %v, Remote = %v", c.MaxOn, c.Remote)
+	}
+}
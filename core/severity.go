@@ -0,0 +1,80 @@
+package core
+
+// Severity classifies a report, so sinks can filter them: a serial log might take everything while
+// a radio uplink only transmits warnings and worse
+type Severity uint8
+
+const (
+	SeverityInfo    Severity = iota // routine: switching, settings, announcements
+	SeverityWarning                 // a command was refused or not understood, or something needs a look
+	SeverityFault                   // a Fault latched, or the load was cut by a fault or reset
+	SeveritySafety                  // an emergency stop
+)
+
+// String returns the Severity's name for use in reports
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityFault:
+		return "fault"
+	case SeveritySafety:
+		return "safety"
+	default:
+		return "unknown"
+	}
+}
+
+// Severity classifies the Report
+func (rep Report) Severity() Severity {
+	switch {
+	case rep.Cause == CauseEStop && (rep.Kind == ReportForcedOff || rep.Kind == ReportOff):
+		return SeveritySafety
+	case rep.Kind == ReportFault:
+		return SeverityFault
+	case rep.Kind == ReportForcedOff && (rep.Cause == CauseFuse || rep.Cause == CauseThermal || rep.Cause == CauseDiagnostic || rep.Cause == CauseReset):
+		return SeverityFault
+	case rep.Error, rep.Kind == ReportRefused, rep.Kind == ReportInvalid:
+		return SeverityWarning
+	}
+	return SeverityInfo
+}
+
+// severity returns t's Severity, taking an error report as at least a warning
+func severity(t Trigger) Severity {
+	if t.Error && t.Severity < SeverityWarning {
+		return SeverityWarning
+	}
+	return t.Severity
+}
+
+// Filter returns a report channel whose reports at min Severity or worse are forwarded to out, and
+// the rest dropped. Reports carry their Severity in Trigger.Severity; error reports count as at
+// least warnings. One goroutine forwards for as long as the program runs.
+func Filter(min Severity, out chan Trigger) chan Trigger {
+	in := make(chan Trigger)
+	go func() {
+		for t := range in {
+			if severity(t) >= min {
+				out <- t
+			}
+		}
+	}()
+	return in
+}
+
+// Tee returns a report channel whose reports are forwarded to each of outs in turn, e.g. to a
+// serial log and, through a Filter, a radio uplink
+func Tee(outs ...chan Trigger) chan Trigger {
+	in := make(chan Trigger)
+	go func() {
+		for t := range in {
+			for _, out := range outs {
+				out <- t
+			}
+		}
+	}()
+	return in
+}
@@ -0,0 +1,67 @@
+package core
+
+import "strconv"
+
+// ShedNever is the shed priority of a Relay that has not been given one; such Relays are never shed
+const ShedNever uint8 = 255
+
+// SetShedPriority sets the Relay's load-shedding priority; lower priorities are shed first
+func (r *relay) SetShedPriority(p uint8) {
+	r.mu.Lock()
+	r.shedPrio = p
+	r.mu.Unlock()
+}
+
+// ShedPriority returns the Relay's load-shedding priority
+func (r *relay) ShedPriority() uint8 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.shedPrio
+}
+
+// Shed reports whether the Relay is currently shed
+func (r *relay) Shed() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.shed
+}
+
+// Shed force-opens every one of relays whose shed priority is at or below level, and refuses
+// them "On" Triggers until they are restored, e.g. for brownout or battery management
+func Shed(level uint8, relays ...Relay) {
+	var shed []*relay
+	for _, rr := range relays {
+		r, ok := rr.(*relay)
+		if !ok {
+			continue
+		}
+		r.mu.Lock()
+		if r.shedPrio != ShedNever && r.shedPrio <= level {
+			r.shed = true // before the cut, so no write can follow it
+			shed = append(shed, r)
+		}
+		r.mu.Unlock()
+	}
+	halt(shed, CauseShed)
+}
+
+// Restore re-admits every shed one of relays whose shed priority is at or above level;
+// Restore(0, ...) re-admits them all. Restored Relays stay off until told otherwise.
+func Restore(level uint8, relays ...Relay) {
+	for _, rr := range relays {
+		r, ok := rr.(*relay)
+		if !ok {
+			continue
+		}
+		r.mu.Lock()
+		if r.shedPrio >= level {
+			r.shed = false
+		}
+		r.mu.Unlock()
+	}
+}
+
+// shedRefusal explains why a Relay shed at priority prio refuses "On"
+func shedRefusal(prio uint8) string {
+	return "refused On, it is shed at priority " + strconv.Itoa(int(prio))
+}
@@ -0,0 +1,54 @@
+package core
+
+// Source says where a command came from
+type Source uint8
+
+const (
+	SourceUnknown  Source = iota // not stated by whoever built the Trigger
+	SourceButton                 // a local button or wall switch
+	SourceSerial                 // a wired serial console or bus
+	SourceRemote                 // the network, e.g. MQTT through a trigger Dispatcher
+	SourceSchedule               // a Scheduler
+	SourceSensor                 // a sensor binding, e.g. Motion
+	SourceInternal               // the package itself or the program, e.g. Resume or a zone walk
+)
+
+// String returns the Source's name for use in reports
+func (s Source) String() string {
+	switch s {
+	case SourceUnknown:
+		return "unknown"
+	case SourceButton:
+		return "button"
+	case SourceSerial:
+		return "serial"
+	case SourceRemote:
+		return "remote"
+	case SourceSchedule:
+		return "schedule"
+	case SourceSensor:
+		return "sensor"
+	case SourceInternal:
+		return "internal"
+	default:
+		return "unknown"
+	}
+}
+
+// Local reports whether the Source is a person at the device
+func (s Source) Local() bool {
+	return s == SourceButton || s == SourceSerial
+}
+
+// SourcePolicy decides whether a command from src may be carried out, returning a refusal or ""
+type SourcePolicy func(src Source, a ParsedAction) string
+
+// SetSourcePolicy makes the Relay refuse Triggers that p refuses, e.g. settings changes from
+// anywhere but the serial console; nil removes the policy
+func (r *relay) SetSourcePolicy(p SourcePolicy) {
+	r.do(func() {
+		r.mu.Lock()
+		r.sourcePolicy = p
+		r.mu.Unlock()
+	})
+}
@@ -0,0 +1,39 @@
+package core_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eyelight/relay/core"
+	"github.com/eyelight/relay/relaytest"
+)
+
+func TestSourcePolicyRefusedOffKeepsOutputOn(t *testing.T) {
+	out := relaytest.NewOutput()
+	r := core.New(out, "pump")
+	r.Configure()
+	r.SetSourcePolicy(func(src core.Source, a core.ParsedAction) string {
+		if a.Op == core.OpOff && !src.Local() {
+			return "Off only from the panel"
+		}
+		return ""
+	})
+	r.Execute(core.Trigger{Target: "pump", Action: core.ActionOn, Source: core.SourceInternal})
+	waitFor(t, "On", r.Get)
+
+	rec := relaytest.NewRecorder()
+	r.Execute(core.Trigger{Target: "pump", Action: core.ActionOff, Source: core.SourceRemote, ReportCh: rec.C()})
+	rec.Expect(t, "Off only from the panel", time.Second)
+	if !out.Get() || !r.Get() {
+		t.Fatal("a refused Off switched the output off")
+	}
+	if n := out.Edges(); n != 1 {
+		t.Errorf("output changed level %d times, want 1", n)
+	}
+
+	r.Execute(core.Trigger{Target: "pump", Action: core.ActionOff, Source: core.SourceButton})
+	waitFor(t, "Off", func() bool { return !r.Get() })
+	if out.Get() {
+		t.Error("output still on after an allowed Off")
+	}
+}
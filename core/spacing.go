@@ -0,0 +1,52 @@
+package core
+
+import (
+	"sync"
+	"time"
+)
+
+// spacer keeps the switching events of Relays sharing a driver at least min apart
+type spacer struct {
+	mu   sync.Mutex
+	min  time.Duration
+	last time.Time
+}
+
+// wait blocks until min has passed since the last switching event through the spacer, then claims
+// the next one; callers queue on the mutex
+func (sp *spacer) wait() {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	if d := sp.min - since(sp.last); !sp.last.IsZero() && d > 0 {
+		time.Sleep(d)
+	}
+	sp.last = now()
+}
+
+// SetSpacing keeps any two switching events of the Bank's Relays at least min apart, e.g. 100ms, so
+// coils behind a shared driver transistor array never draw their inrush current at once; a
+// transition waits its turn. The immediate cut Execute makes for an "Off" doesn't wait, as breaking
+// a coil's current draws none. 0 removes the spacing.
+func (b *Bank) SetSpacing(min time.Duration) {
+	var sp *spacer
+	if min > 0 {
+		sp = &spacer{min: min}
+	}
+	for _, rr := range b.relays {
+		if r, ok := rr.(*relay); ok {
+			r.mu.Lock()
+			r.spacer = sp
+			r.mu.Unlock()
+		}
+	}
+}
+
+// space waits for the Relay's turn to switch, if it shares a spaced Bank
+func (r *relay) space() {
+	r.mu.Lock()
+	sp := r.spacer
+	r.mu.Unlock()
+	if sp != nil {
+		sp.wait()
+	}
+}
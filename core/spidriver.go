@@ -0,0 +1,107 @@
+package core
+
+import (
+	"strconv"
+	"sync"
+)
+
+// SPI is the part of an SPI bus an SPIDriver needs; TinyGo's machine.SPI satisfies it
+type SPI interface {
+	Tx(w, r []byte) error
+}
+
+// SPIDriver is an SPI-controlled 8-channel power driver IC with diagnostic readback, such as a
+// high-side driver used in place of relays. Each transfer writes the output byte (bit n drives
+// channel n) and reads back two diagnostic bytes: open-load flags, then overtemperature/short flags,
+// per channel. Drivers with other register maps can be wrapped to present the same framing. Its
+// Outputs are Diagnosers, so Relays on them trip on driver faults once SetDiagnostics is called.
+type SPIDriver struct {
+	bus      SPI
+	cs       Output // chip select, active low
+	mu       sync.Mutex
+	port     uint8 // outputs last written
+	openLoad uint8 // diagnostic flags from the last transfer
+	overtemp uint8
+	err      error
+}
+
+// NewSPIDriver returns an SPIDriver on bus selected by cs, which it configures, with every channel off
+func NewSPIDriver(bus SPI, cs Output) *SPIDriver {
+	cs.Configure()
+	cs.Set(true)
+	return &SPIDriver{
+		bus: bus,
+		cs:  cs,
+	}
+}
+
+// Output returns an Output driving channel n (0-7)
+func (d *SPIDriver) Output(n uint8) Output {
+	return spiOutput{d: d, bit: 1 << (n & 7)}
+}
+
+// transfer writes the outputs and reads back the diagnostics; d.mu must be held
+func (d *SPIDriver) transfer() {
+	w := [3]byte{d.port}
+	var r [3]byte
+	d.cs.Set(false)
+	d.err = d.bus.Tx(w[:], r[:])
+	d.cs.Set(true)
+	if d.err == nil {
+		d.openLoad, d.overtemp = r[1], r[2]
+	}
+}
+
+// diagnose returns the Fault the driver reports for the channel at bit, or NoFault
+func (d *SPIDriver) diagnose(bit uint8) Fault {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.transfer()
+	switch {
+	case d.overtemp&bit != 0 && d.port&bit != 0:
+		return FaultShort // a channel switched on and shut down hot is taken to be shorted
+	case d.overtemp&bit != 0:
+		return FaultOvertemp
+	case d.openLoad&bit != 0:
+		return FaultOpenLoad
+	}
+	return NoFault
+}
+
+// spiOutput drives one channel of an SPIDriver
+type spiOutput struct {
+	d   *SPIDriver
+	bit uint8
+}
+
+func (o spiOutput) Configure() {}
+
+func (o spiOutput) Set(level bool) {
+	o.d.mu.Lock()
+	defer o.d.mu.Unlock()
+	if level {
+		o.d.port |= o.bit
+	} else {
+		o.d.port &^= o.bit
+	}
+	o.d.transfer()
+}
+
+// Diagnose returns the Fault the driver reports for the channel
+func (o spiOutput) Diagnose() Fault {
+	return o.d.diagnose(o.bit)
+}
+
+func (o spiOutput) Get() bool {
+	o.d.mu.Lock()
+	defer o.d.mu.Unlock()
+	return o.d.port&o.bit != 0
+}
+
+func (o spiOutput) String() string {
+	n := 0
+	for b := o.bit; b > 1; b >>= 1 {
+		n++
+	}
+	return "SPI driver channel " + strconv.Itoa(n)
+}
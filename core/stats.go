@@ -0,0 +1,82 @@
+package core
+
+import "time"
+
+// StatsScope selects the counters ResetStats clears
+type StatsScope uint8
+
+const (
+	StatsDaily  StatsScope = iota // the day's cycles and on-time
+	StatsTotal                    // the lifetime cycles and on-time, and with them the daily and energy counters
+	StatsEnergy                   // the energy used
+)
+
+// SetLoadPower sets the power the load draws when on, in watts, from which Stats estimates the energy used
+func (r *relay) SetLoadPower(watts float32) {
+	r.do(func() {
+		r.mu.Lock()
+		r.watts = watts
+		r.mu.Unlock()
+	})
+}
+
+// ResetStats clears the counters in scope, e.g. at the end of a billing cycle. The duty budget
+// is unaffected.
+func (r *relay) ResetStats(scope StatsScope) {
+	r.do(func() {
+		r.resetStats(scope)
+	})
+}
+
+// resetStats clears the counters in scope; it is only ever called from the worker
+func (r *relay) resetStats(scope StatsScope) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	onTime := r.stats().OnTime
+	switch scope {
+	case StatsDaily:
+		r.dayCycles = r.cycles
+		r.dayOnTime = onTime
+	case StatsEnergy:
+		r.energyOnTime = onTime
+		r.energyBandBase = r.stats().OnTimeByBand
+	case StatsTotal:
+		r.dutyBase -= onTime // keeps the duty window's usage
+		r.cycles = 0
+		r.onTotal = 0
+		if r.on {
+			r.lastOn = now()
+		}
+		r.dayCycles, r.dayOnTime, r.energyOnTime = 0, 0, 0
+		r.byBand, r.energyBandBase = [BandCount]time.Duration{}, [BandCount]time.Duration{}
+	}
+}
+
+// SetRollover resets the daily counters at every local midnight, or stops doing so
+func (r *relay) SetRollover(on bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.rollover != nil {
+		r.rollover.Stop()
+		r.rollover = nil
+	}
+	if on {
+		r.rollover = time.AfterFunc(untilMidnight(time.Now()), r.rollOver)
+	}
+}
+
+// rollOver resets the daily counters and schedules the next rollover
+func (r *relay) rollOver() {
+	r.ResetStats(StatsDaily)
+	r.mu.Lock()
+	if r.rollover != nil {
+		r.rollover.Reset(untilMidnight(time.Now()))
+	}
+	r.mu.Unlock()
+}
+
+// untilMidnight returns the time from t to the next local midnight
+func untilMidnight(t time.Time) time.Duration {
+	t = t.Local()
+	return time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.Local).Sub(t)
+}
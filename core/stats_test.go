@@ -0,0 +1,49 @@
+package core_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eyelight/relay/core"
+	"github.com/eyelight/relay/relaytest"
+)
+
+// waitFor polls cond until it holds or a second has passed
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestStatsWhileSwitching(t *testing.T) {
+	r := core.New(relaytest.NewOutput(), "pump")
+	r.Configure()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			r.Execute(core.Trigger{Target: "pump", Action: core.ActionOn, Source: core.SourceInternal})
+			r.Execute(core.Trigger{Target: "pump", Action: core.ActionOff, Source: core.SourceInternal})
+		}
+	}()
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+			r.Stats()
+		}
+	}
+	r.Execute(core.Trigger{Target: "pump", Action: core.ActionOn, Source: core.SourceInternal})
+	waitFor(t, "a cycle", func() bool { return r.Stats().Cycles > 0 })
+	r.Execute(core.Trigger{Target: "pump", Action: core.ActionOff, Source: core.SourceInternal})
+	waitFor(t, "Off", func() bool { return !r.Get() })
+	if st := r.Stats(); st.OnTime <= 0 {
+		t.Errorf("OnTime = %v after switching, want > 0", st.OnTime)
+	}
+}
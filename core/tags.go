@@ -0,0 +1,55 @@
+package core
+
+import (
+	"sort"
+	"strings"
+)
+
+// SetTag attaches a small piece of metadata to the Relay, e.g. SetTag("location", "greenhouse"),
+// for fleet-management backends to organize channels by. An empty value removes the tag.
+func (r *relay) SetTag(key, value string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if value == "" {
+		delete(r.tags, key)
+		return
+	}
+	if r.tags == nil {
+		r.tags = make(map[string]string, 4)
+	}
+	r.tags[key] = value
+}
+
+// Tags returns a copy of the Relay's metadata
+func (r *relay) Tags() map[string]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tags := make(map[string]string, len(r.tags))
+	for k, v := range r.tags {
+		tags[k] = v
+	}
+	return tags
+}
+
+// writeTags writes the Relay's tags as " [k=v k=v]" in key order, or nothing if it has none
+func (r *relay) writeTags(ss *strings.Builder) {
+	tags := r.Tags()
+	if len(tags) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	ss.WriteString(" [")
+	for i, k := range keys {
+		if i > 0 {
+			ss.WriteString(" ")
+		}
+		ss.WriteString(k)
+		ss.WriteString("=")
+		ss.WriteString(tags[k])
+	}
+	ss.WriteString("]")
+}
@@ -0,0 +1,146 @@
+package core
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Band is a time-of-use tariff band
+type Band uint8
+
+const (
+	BandStandard Band = iota // any time outside the Tariff's windows
+	BandOffPeak
+	BandPeak
+	BandCount // the number of Bands, for arrays indexed by Band
+)
+
+// String returns the Band's name for use in reports
+func (b Band) String() string {
+	switch b {
+	case BandStandard:
+		return "standard"
+	case BandOffPeak:
+		return "off-peak"
+	case BandPeak:
+		return "peak"
+	default:
+		return "unknown"
+	}
+}
+
+// TariffWindow is a daily window of local time in one tariff Band. From and To are times of day
+// since midnight; a window may span midnight (From 23h, To 7h).
+type TariffWindow struct {
+	From time.Duration
+	To   time.Duration
+	Band Band
+}
+
+// Tariff is a day of time-of-use windows, which should not overlap; other times are BandStandard
+type Tariff []TariffWindow
+
+// tariff holds the Tariff set by SetTariff
+var tariff atomic.Value
+
+// SetTariff sets the time-of-use Tariff by which Stats break down on-time and energy, and peak
+// policies and Schedules that avoid the peak tell peak from off-peak. Breakdowns begin from then.
+func SetTariff(t Tariff) {
+	tariff.Store(t)
+}
+
+// currentTariff returns the Tariff set by SetTariff, or nil
+func currentTariff() Tariff {
+	t, _ := tariff.Load().(Tariff)
+	return t
+}
+
+// band returns the Band at t and how long it lasts from t; 0 if the Tariff has no windows
+func (tf Tariff) band(t time.Time) (Band, time.Duration) {
+	if len(tf) == 0 {
+		return BandStandard, 0
+	}
+	t = t.Local()
+	tod := t.Sub(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local))
+	next := 24 * time.Hour
+	for _, w := range tf {
+		if left := (Quiet{From: w.From, To: w.To}).left(t); left > 0 {
+			return w.Band, left
+		}
+		until := w.From - tod
+		if until <= 0 {
+			until += 24 * time.Hour
+		}
+		if until < next {
+			next = until
+		}
+	}
+	return BandStandard, next
+}
+
+// split divides the time from from to to among the Bands it falls in
+func (tf Tariff) split(from, to time.Time, add func(b Band, d time.Duration)) {
+	for from.Before(to) {
+		b, left := tf.band(from)
+		end := from.Add(left)
+		if left <= 0 || end.After(to) {
+			end = to
+		}
+		add(b, end.Sub(from))
+		from = end
+	}
+}
+
+// PeakPolicy says what happens to an "On" Trigger during the Tariff's peak
+type PeakPolicy uint8
+
+const (
+	PeakAllow  PeakPolicy = iota // carry out the On
+	PeakRefuse                   // refuse the On
+	PeakDefer                    // carry out the On when the peak ends
+)
+
+// SetPeakPolicy has the Relay refuse or defer "On" Triggers during the Tariff's peak, for loads
+// that can wait for cheaper energy; PeakAllow, the default, ignores the Tariff
+func (r *relay) SetPeakPolicy(p PeakPolicy) {
+	r.do(func() {
+		r.mu.Lock()
+		r.peakPolicy = p
+		r.mu.Unlock()
+	})
+}
+
+// holdPeak defers or refuses an "On" Trigger made during the peak, returning true if it did so
+func (r *relay) holdPeak(t Trigger) bool {
+	if r.peakPolicy == PeakAllow {
+		return false
+	}
+	b, left := currentTariff().band(time.Now())
+	if b != BandPeak {
+		return false
+	}
+	if r.peakPolicy == PeakRefuse {
+		r.reject(t, "refused On during the peak tariff, which ends in "+left.String())
+		return true
+	}
+	time.AfterFunc(left, func() {
+		r.Execute(t)
+	})
+	r.report(t, Report{Kind: ReportInfo, Detail: "On deferred by " + left.String() + " to the end of the peak tariff"})
+	return true
+}
+
+// describePeak describes what an "On" made now would run into, or returns "" if it would go ahead
+func (r *relay) describePeak() (string, bool) {
+	if r.peakPolicy == PeakAllow {
+		return "", true
+	}
+	b, left := currentTariff().band(time.Now())
+	if b != BandPeak {
+		return "", true
+	}
+	if r.peakPolicy == PeakRefuse {
+		return "would refuse On during the peak tariff, which ends in " + left.String(), false
+	}
+	return "would defer On by " + left.String() + " to the end of the peak tariff", true
+}
@@ -0,0 +1,103 @@
+package core
+
+import (
+	"sync"
+	"time"
+)
+
+// TPO drives a Relay with time-proportioned output: the Relay is switched on for the
+// output percentage of each window, turning a slow on/off load into a variable one.
+type TPO struct {
+	relay  Relay
+	window time.Duration
+	mu     sync.Mutex // guards output
+	output float32
+	stop   chan struct{}
+	group  *TPOGroup // staggers this TPO's on-period against others; nil if ungrouped
+}
+
+// NewTPO returns a TPO engine for r with the given window length, at 0% output.
+// Windows of a few seconds suit SSRs; mechanical relays want a minute or more.
+func NewTPO(r Relay, window time.Duration) *TPO {
+	return &TPO{
+		relay:  r,
+		window: window,
+		output: 0,
+		stop:   make(chan struct{}),
+	}
+}
+
+// SetOutput sets the percentage (0-100) of each window for which the Relay is on, starting with the next window
+func (p *TPO) SetOutput(pct float32) {
+	if pct < 0 {
+		pct = 0
+	} else if pct > 100 {
+		pct = 100
+	}
+	p.mu.Lock()
+	p.output = pct
+	p.mu.Unlock()
+}
+
+// Output returns the current output percentage
+func (p *TPO) Output() float32 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.output
+}
+
+// Run switches the Relay window after window until Stop is called, leaving it off. In a TPOGroup,
+// the windows line up with the group's and the on-period starts at the TPO's phase in the group.
+func (p *TPO) Run() {
+	defer p.relay.Off()
+	if p.group != nil && !p.wait(p.group.untilWindow()) {
+		return
+	}
+	for {
+		on := time.Duration(float32(p.window) * p.Output() / 100)
+		var phase time.Duration
+		if p.group != nil {
+			phase = p.group.phase(p)
+		}
+		// the on-period covers [phase, phase+on) of the window, wrapping around its end
+		end := phase + on
+		var wrap time.Duration
+		if end > p.window {
+			wrap = end - p.window
+			end = p.window
+		}
+		if !p.hold(true, wrap) || !p.hold(false, phase-wrap) || !p.hold(true, end-phase) || !p.hold(false, p.window-end) {
+			return
+		}
+	}
+}
+
+// hold switches the Relay to s for d, doing nothing if d is not positive; it returns false if the TPO was stopped meanwhile
+func (p *TPO) hold(s bool, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+	if s {
+		p.relay.On()
+	} else {
+		p.relay.Off()
+	}
+	return p.wait(d)
+}
+
+// Stop ends Run
+func (p *TPO) Stop() {
+	close(p.stop)
+}
+
+// wait sleeps for d, returning false if the TPO was stopped meanwhile
+func (p *TPO) wait(d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-p.stop:
+		return false
+	}
+}
@@ -0,0 +1,50 @@
+package core
+
+import "time"
+
+// TPOGroup staggers the on-periods of TPO engines sharing a window length – e.g. a bank of heaters –
+// so that rather than all switching on at the start of each window, each starts where the previous
+// one's on-period ends. With outputs summing to 150%, no more than two loads are ever on at once,
+// keeping the total current draw flat.
+type TPOGroup struct {
+	window  time.Duration
+	start   time.Time
+	members []*TPO
+}
+
+// NewTPOGroup groups tpos, which must share a window length, in the order given. Group them
+// before calling their Run methods.
+func NewTPOGroup(tpos ...*TPO) *TPOGroup {
+	g := &TPOGroup{
+		start:   now(),
+		members: tpos,
+	}
+	for _, p := range tpos {
+		g.window = p.window
+		p.group = g
+	}
+	return g
+}
+
+// phase returns the offset of p's on-period into each window: the on-time of the members before it, wrapped
+func (g *TPOGroup) phase(p *TPO) time.Duration {
+	var offset time.Duration
+	for _, m := range g.members {
+		if m == p {
+			break
+		}
+		offset += time.Duration(float32(g.window) * m.Output() / 100)
+	}
+	if g.window <= 0 {
+		return 0
+	}
+	return offset % g.window
+}
+
+// untilWindow returns the time until the group's next window starts
+func (g *TPOGroup) untilWindow() time.Duration {
+	if g.window <= 0 {
+		return 0
+	}
+	return g.window - since(g.start)%g.window
+}
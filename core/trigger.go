@@ -0,0 +1,17 @@
+package core
+
+import "time"
+
+// Trigger is a command for a Relay or Scheduler, sent back on its ReportCh – with Message and
+// Error filled in – as the report of what became of it. It mirrors github.com/eyelight/trigger's
+// Trigger field for field; package triggerable converts between the two.
+type Trigger struct {
+	Target   string
+	Action   Action
+	Duration time.Duration
+	Message  string
+	Error    bool
+	ReportCh chan Trigger
+	Source   Source   // where the command came from; not part of github.com/eyelight/trigger's Trigger
+	Severity Severity // of a report, for filtering; not part of github.com/eyelight/trigger's Trigger
+}
@@ -0,0 +1,19 @@
+package core
+
+// Trip latches Fault f from outside the Relay, e.g. from a driver's diagnostics, switching the load
+// off and reporting the fault, with detail, to the Relay's report fallback. A Fault already latched
+// is kept; the Relay refuses "On" until ClearFault.
+func (r *relay) Trip(f Fault, detail string) {
+	r.do(func() {
+		if r.Fault() != NoFault || f == NoFault {
+			return
+		}
+		r.latch(f, detail)
+		elapsed := r.elapsed()
+		if !r.on {
+			elapsed = 0
+		}
+		r.forceOff(CauseDiagnostic)
+		r.report(Trigger{Target: r.Name(), Action: "Trip"}, Report{Kind: ReportFault, Error: true, Fault: f, Detail: detail, Elapsed: elapsed})
+	})
+}
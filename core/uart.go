@@ -0,0 +1,43 @@
+package core
+
+import (
+	"io"
+	"strconv"
+)
+
+// uartOutput drives one channel of a serial-controlled relay board using the LC Technology protocol
+// common to cheap UART/USB relay modules: 0xA0, channel, state, checksum
+type uartOutput struct {
+	w       io.Writer
+	channel uint8
+	level   bool
+}
+
+// UARTOutput returns an Output driving channel (from 1) of a serial-controlled relay board written to
+// through w, typically a *machine.UART configured for the board's baud rate (usually 9600). Such boards
+// give no readback, so Get returns the last level written.
+func UARTOutput(w io.Writer, channel uint8) Output {
+	return &uartOutput{
+		w:       w,
+		channel: channel,
+	}
+}
+
+func (o *uartOutput) Configure() {}
+
+func (o *uartOutput) Set(level bool) {
+	var state uint8
+	if level {
+		state = 1
+	}
+	o.w.Write([]byte{0xA0, o.channel, state, 0xA0 + o.channel + state})
+	o.level = level
+}
+
+func (o *uartOutput) Get() bool {
+	return o.level
+}
+
+func (o *uartOutput) String() string {
+	return "UART channel " + strconv.Itoa(int(o.channel))
+}
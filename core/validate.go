@@ -0,0 +1,128 @@
+package core
+
+import "strings"
+
+// validatePrefix marks a Trigger as a dry run, e.g. Action "Validate On": the Trigger is checked
+// and the would-be outcome reported, but nothing is switched or changed
+const validatePrefix = string(ActionValidate) + " "
+
+// Validate checks t against the Relay's target, action, fault state, budget and limits and describes
+// what Execute would do with it, without doing it. ok is false if t would be refused.
+func (r *relay) Validate(t Trigger) (string, bool) {
+	detail, ok := r.dryRun(t)
+	return r.format(Report{
+		Relay:  r.Name(),
+		Action: t.Action,
+		Kind:   ReportDryRun,
+		Error:  !ok,
+		Time:   now(),
+		Detail: detail,
+	}), ok
+}
+
+// dryRun describes what handling t would do, without doing it
+func (r *relay) dryRun(t Trigger) (msg string, ok bool) {
+	if t.Target != r.Name() {
+		return "would refuse a trigger intended for " + t.Target, false
+	}
+	a, err := Parse(t.Action, t.Duration)
+	if err != nil {
+		return "would refuse: " + err.Error(), false
+	}
+	a = a.resolved(r.Get())
+	if a.Else != "" {
+		defer func() {
+			if ok {
+				msg += ", and fall back to " + string(a.Else) + " unless another command arrives within " + a.ElseAfter.String()
+			}
+		}()
+	}
+	switch a.Op {
+	case OpOn:
+		if refusal := r.overridden(t.Source); refusal != "" {
+			return refusal, false
+		}
+		if refusal := r.refuseOn(); refusal != "" {
+			return refusal, false
+		}
+		if msg, ok := r.describeQuiet(); msg != "" {
+			return msg, ok
+		}
+		if msg, ok := r.describePeak(); msg != "" {
+			return msg, ok
+		}
+		d := r.limit(a.requested())
+		if r.current() == nil {
+			if msg, ok := r.describePrereqs(); msg != "" {
+				return msg, ok
+			}
+			if msg, ok := r.interlocked(); msg != "" {
+				return msg, ok
+			}
+			if d <= 0 {
+				return "would switch On indefinitely", true
+			}
+			return "would switch On for " + d.String(), true
+		}
+		if d == indefinite {
+			return "would run on indefinitely", true
+		}
+		if d <= 0 {
+			if msg, ok := r.describeHold(); msg != "" {
+				return msg, ok
+			}
+			return "would switch Off after " + r.elapsed().String(), true
+		}
+		if r.retriggers() && d > 0 {
+			return "would restart its countdown, Off in " + d.String(), true
+		}
+		if d == r.runDuration() {
+			return "would leave its " + d.String() + " run unchanged", true
+		}
+		return "would change On duration to " + d.String() + " (after " + r.elapsed().String() + " of a scheduled " + r.runDuration().String() + ")", true
+	case OpOff:
+		if !r.Get() {
+			return "is already Off", true
+		}
+		if msg, ok := r.describeHold(); msg != "" {
+			return msg, ok
+		}
+		return "would switch Off after " + r.elapsed().String(), true
+	case OpSetting:
+		if refusal := r.settingRefusal(a.Setting); refusal != "" {
+			return refusal, false
+		}
+		if a.Setting == SettingName {
+			return "would rename itself " + a.Name, true
+		}
+		return "would set " + strings.TrimPrefix(a.Setting, "Set") + " to " + a.Duration.String(), true
+	case OpAnnounce:
+		return "would announce itself", true
+	}
+	return "would refuse: nothing to do", false
+}
+
+// refuseOn explains why an "On" Trigger would be refused right now, or returns ""
+func (r *relay) refuseOn() string {
+	if refusal := r.barred(); refusal != "" {
+		return refusal
+	}
+	if left, ok := r.dutyRemaining(); ok && left <= 0 {
+		r.mu.Lock()
+		budget := r.dutyBudget
+		r.mu.Unlock()
+		return "refused On, its " + budget.String() + " duty budget is spent"
+	}
+	if r.vote != nil {
+		return r.vote.refusal()
+	}
+	return ""
+}
+
+// handleValidate reports the outcome of a dry-run Trigger
+func (r *relay) handleValidate(t Trigger) {
+	t.Action = Action(strings.TrimPrefix(string(t.Action), validatePrefix))
+	detail, ok := r.dryRun(t)
+	t.Action = Action(validatePrefix) + t.Action
+	r.send(t, Report{Kind: ReportDryRun, Error: !ok, Detail: detail})
+}
@@ -0,0 +1,56 @@
+package core
+
+import "sync"
+
+// funcOutput actuates a virtual Relay through callbacks
+type funcOutput struct {
+	on    func() error
+	off   func() error
+	mu    sync.Mutex // guards level
+	level bool
+}
+
+// FuncOutput returns an Output that calls on or off instead of driving a pin, e.g. to send an
+// RF433 code or call a Tasmota HTTP endpoint. If a callback returns an error the Output keeps
+// its previous level, so the Relay's measured confirmation reports the failure.
+func FuncOutput(on, off func() error) Output {
+	return &funcOutput{
+		on:  on,
+		off: off,
+	}
+}
+
+// NewVirtual returns a Relay actuated by the on and off callbacks, with all the scheduling,
+// limits and reporting of a pin-driven one
+func NewVirtual(name string, on, off func() error) Relay {
+	return New(FuncOutput(on, off), name)
+}
+
+func (o *funcOutput) Configure() {}
+
+func (o *funcOutput) Set(level bool) {
+	f := o.off
+	if level {
+		f = o.on
+	}
+	if f() == nil {
+		o.mu.Lock()
+		o.level = level
+		o.mu.Unlock()
+	}
+}
+
+func (o *funcOutput) Get() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.level
+}
+
+// Slow reports that the callbacks may take a while, so an "Off" is left to the worker
+func (o *funcOutput) Slow() bool {
+	return true
+}
+
+func (o *funcOutput) String() string {
+	return "virtual"
+}
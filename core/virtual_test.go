@@ -0,0 +1,28 @@
+package core_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/eyelight/relay/core"
+)
+
+func TestVirtualOffCallsBackOnce(t *testing.T) {
+	var ons, offs int32
+	r := core.NewVirtual("socket",
+		func() error { atomic.AddInt32(&ons, 1); return nil },
+		func() error { atomic.AddInt32(&offs, 1); return nil })
+	r.Configure()
+	atomic.StoreInt32(&offs, 0)
+	r.Execute(core.Trigger{Target: "socket", Action: core.ActionOn, Duration: time.Hour, Source: core.SourceInternal})
+	waitFor(t, "On", r.Get)
+	r.Execute(core.Trigger{Target: "socket", Action: core.ActionOff, Source: core.SourceInternal})
+	waitFor(t, "Off", func() bool { _, ok := r.Remaining(); return !ok })
+	if n := atomic.LoadInt32(&ons); n != 1 {
+		t.Errorf("on callback called %d times, want 1", n)
+	}
+	if n := atomic.LoadInt32(&offs); n != 1 {
+		t.Errorf("off callback called %d times, want 1", n)
+	}
+}
@@ -0,0 +1,65 @@
+package core
+
+import "strconv"
+
+// Vote is an N-of-M voting gate for critical loads: a Relay gated by a Vote refuses "On" unless at
+// least need of its inputs confirm it, e.g. two of three temperature channels reading above a
+// threshold, so that one failed sensor can neither start nor block the load on its own.
+type Vote struct {
+	need   int
+	inputs []func() bool
+}
+
+// NewVote returns a Vote passing when at least need of inputs return true
+func NewVote(need int, inputs ...func() bool) *Vote {
+	return &Vote{
+		need:   need,
+		inputs: inputs,
+	}
+}
+
+// Above returns a Vote input confirming while read returns more than threshold
+func Above(read func() float32, threshold float32) func() bool {
+	return func() bool {
+		return read() > threshold
+	}
+}
+
+// Below returns a Vote input confirming while read returns less than threshold
+func Below(read func() float32, threshold float32) func() bool {
+	return func() bool {
+		return read() < threshold
+	}
+}
+
+// Count polls the inputs and returns how many confirm
+func (v *Vote) Count() int {
+	n := 0
+	for _, in := range v.inputs {
+		if in() {
+			n++
+		}
+	}
+	return n
+}
+
+// Passed reports whether enough inputs confirm
+func (v *Vote) Passed() bool {
+	return v.Count() >= v.need
+}
+
+// refusal explains why the Vote fails, or returns ""
+func (v *Vote) refusal() string {
+	n := v.Count()
+	if n >= v.need {
+		return ""
+	}
+	return "refused On, " + strconv.Itoa(n) + " of " + strconv.Itoa(len(v.inputs)) + " inputs confirm and " + strconv.Itoa(v.need) + " are needed"
+}
+
+// SetVote gates "On" Triggers behind v; nil removes the gate. The On, Off and Set methods are not gated.
+func (r *relay) SetVote(v *Vote) {
+	r.do(func() {
+		r.vote = v
+	})
+}
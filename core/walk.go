@@ -0,0 +1,52 @@
+package core
+
+import (
+	"strconv"
+	"time"
+)
+
+// Walk runs every Relay in the Bank on for each, one after another in channel order, so an
+// installer can walk the site and check each zone's wiring. Progress is reported to reportCh
+// before each channel and when the walk ends; closing stop (which may be nil) ends the walk early,
+// switching the current channel off. Walk blocks until the walk is over.
+func (b *Bank) Walk(each time.Duration, reportCh chan Trigger, stop <-chan struct{}) {
+	n := strconv.Itoa(len(b.relays))
+	for i, r := range b.relays {
+		post(reportCh, r, Report{
+			Relay:  b.name,
+			Action: "Walk",
+			Kind:   ReportProgress,
+			Detail: "zone walk " + strconv.Itoa(i+1) + "/" + n + ": " + r.Name() + " On for " + each.String(),
+		})
+		r.Execute(Trigger{
+			Target:   r.Name(),
+			Action:   ActionOn,
+			Duration: each,
+			ReportCh: reportCh,
+			Source:   SourceInternal,
+		})
+		t := time.NewTimer(each)
+		select {
+		case <-t.C:
+		case <-stop:
+			t.Stop()
+			r.Execute(Trigger{Target: r.Name(), Action: ActionOff, ReportCh: reportCh, Source: SourceInternal})
+			post(reportCh, r, Report{
+				Relay:  b.name,
+				Action: "Walk",
+				Kind:   ReportProgress,
+				Detail: "zone walk stopped at " + strconv.Itoa(i+1) + "/" + n + ": " + r.Name(),
+			})
+			return
+		}
+		if r.Get() { // a minimum on-time or deferral outlasted each
+			r.Execute(Trigger{Target: r.Name(), Action: ActionOff, ReportCh: reportCh, Source: SourceInternal})
+		}
+	}
+	post(reportCh, nil, Report{
+		Relay:  b.name,
+		Action: "Walk",
+		Kind:   ReportProgress,
+		Detail: "zone walk finished, " + n + " channels",
+	})
+}
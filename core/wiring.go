@@ -0,0 +1,94 @@
+package core
+
+// Input is a digital sense line, e.g. a relay's auxiliary contact or an optocoupler across its load.
+// A machine.Pin configured as an input is one.
+type Input interface {
+	Get() bool
+}
+
+// Wiring declares how a channel is expected to be wired, for CheckWiring
+type Wiring struct {
+	Relay             Relay
+	ActiveLow         bool  // the board energizes the coil by driving the pin low
+	NormallyClosed    bool  // the load is on the normally-closed contact
+	Feedback          Input // senses the load, or nil if the channel has no feedback
+	FeedbackActiveLow bool  // Feedback reads low while the load is on
+}
+
+// WiringAnomaly is a mismatch CheckWiring found between a channel's declared wiring and what it reads
+type WiringAnomaly struct {
+	Relay  string
+	Detail string
+}
+
+// CheckWiring compares each channel's configuration and idle readings with its declared Wiring,
+// reporting each anomaly to reportCh (or the console if it is nil) and returning them all. Call it
+// at startup, after the Relays have been configured and before they are put to work; channels
+// already on are skipped, as their readings aren't idle ones.
+func CheckWiring(reportCh chan Trigger, wiring ...Wiring) []WiringAnomaly {
+	var found []WiringAnomaly
+	for _, w := range wiring {
+		for _, detail := range w.check() {
+			a := WiringAnomaly{Relay: w.Relay.Name(), Detail: detail}
+			found = append(found, a)
+			t := Trigger{
+				Target:  a.Relay,
+				Action:  "CheckWiring",
+				Message: "error - " + a.Relay + " wiring: " + a.Detail,
+				Error:   true,
+			}
+			if reportCh == nil {
+				println(t.Message)
+				continue
+			}
+			reportCh <- t
+		}
+	}
+	return found
+}
+
+// check describes how the channel's configuration and idle readings differ from its declared Wiring
+func (w Wiring) check() []string {
+	var found []string
+	c := w.Relay.Config()
+	if c.ActiveLow != w.ActiveLow {
+		found = append(found, "configured "+polarity(c.ActiveLow)+" but wired "+polarity(w.ActiveLow))
+	}
+	if c.NormallyClosed != w.NormallyClosed {
+		found = append(found, "configured for the "+contact(c.NormallyClosed)+" contact but wired to the "+contact(w.NormallyClosed)+" one")
+	}
+	if _, running := w.Relay.Remaining(); running {
+		return found
+	}
+	if w.Relay.Coil() != c.NormallyClosed {
+		found = append(found, "its pin reads back the coil "+energized(w.Relay.Coil())+" while idle; is it driven, or shorted?")
+	}
+	if w.Feedback != nil && w.Feedback.Get() != w.FeedbackActiveLow {
+		found = append(found, "feedback senses the load on while idle: a welded contact, a miswired feedback line, or its polarity")
+	}
+	return found
+}
+
+// polarity names a coil drive polarity
+func polarity(activeLow bool) string {
+	if activeLow {
+		return "active-low"
+	}
+	return "active-high"
+}
+
+// contact names the contact a load is wired to
+func contact(nc bool) string {
+	if nc {
+		return "normally-closed"
+	}
+	return "normally-open"
+}
+
+// energized describes a coil's state
+func energized(on bool) string {
+	if on {
+		return "energized"
+	}
+	return "de-energized"
+}
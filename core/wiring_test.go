@@ -0,0 +1,35 @@
+package core_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eyelight/relay/core"
+	"github.com/eyelight/relay/relaytest"
+)
+
+func TestWiringChangedWhileRunning(t *testing.T) {
+	out := relaytest.NewOutput()
+	r := core.New(out, "pump")
+	r.Configure()
+	tpo := core.NewTPO(r, 5*time.Millisecond)
+	tpo.SetOutput(50)
+	go tpo.Run()
+	for i := 0; i < 20; i++ {
+		r.SetActiveLow(i%2 == 0)
+		r.SetNormallyClosed(i%4 < 2)
+		r.Get()
+		r.Config()
+		time.Sleep(time.Millisecond)
+	}
+	tpo.Stop()
+	r.SetActiveLow(true)
+	r.SetNormallyClosed(false)
+	r.Off()
+	if !out.Get() {
+		t.Error("an active-low Relay switched off left its pin low")
+	}
+	if r.Get() {
+		t.Error("Get reports the load on after Off")
+	}
+}
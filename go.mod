@@ -1,7 +1,3 @@
 module github.com/eyelight/relay
 
 go 1.18
-
-replace github.com/eyelight/trigger => /home/jason/go/src/github.com/eyelight/trigger
-
-require github.com/eyelight/trigger v0.0.0
\ No newline at end of file
@@ -0,0 +1,54 @@
+package relay
+
+import (
+	"errors"
+	"machine"
+	"strconv"
+
+	"github.com/eyelight/relay/core"
+)
+
+// ErrUnknownLayout is returned when no Layout has been registered under the requested name
+var ErrUnknownLayout = errors.New("relay: unknown board layout")
+
+// Layout describes a multi-channel relay board: the pin driving each channel in silkscreen order,
+// whether the board's inputs are active-low, and optional channel names
+type Layout struct {
+	Name      string
+	Pins      []machine.Pin
+	ActiveLow bool
+	Channels  []string // names for each channel; missing names default to "<Layout.Name>/<n>"
+}
+
+var layouts = map[string]Layout{}
+
+// RegisterLayout makes a Layout available to NewBankFromLayout under its name, e.g. "waveshare-8ch".
+// Pin assignments depend on how the board is wired to your microcontroller, so layouts are
+// registered by the application rather than shipped with the package.
+func RegisterLayout(l Layout) {
+	layouts[l.Name] = l
+}
+
+// NewBank returns a core.Bank with a Relay for each of the Layout's pins, ready to be configured
+func NewBank(l Layout) *core.Bank {
+	relays := make([]Relay, len(l.Pins))
+	for i, p := range l.Pins {
+		name := l.Name + "/" + strconv.Itoa(i+1)
+		if i < len(l.Channels) && l.Channels[i] != "" {
+			name = l.Channels[i]
+		}
+		r := New(p, name)
+		r.SetActiveLow(l.ActiveLow)
+		relays[i] = r
+	}
+	return core.NewBank(l.Name, relays...)
+}
+
+// NewBankFromLayout returns a core.Bank for the Layout registered under name
+func NewBankFromLayout(name string) (*core.Bank, error) {
+	l, ok := layouts[name]
+	if !ok {
+		return nil, ErrUnknownLayout
+	}
+	return NewBank(l), nil
+}
@@ -0,0 +1,105 @@
+package relay
+
+import (
+	"machine"
+	"time"
+
+	"github.com/eyelight/relay/core"
+)
+
+// motionPoll is how often the sensor pin is read where pin interrupts aren't available
+const motionPoll = 50 * time.Millisecond
+
+// Motion switches a lighting Relay on when a PIR or other motion sensor sees movement, and off
+// once no movement has been seen for the hold time – each detection restarts the hold time, as
+// does the sensor letting go, and a sensor held high by continuous movement keeps restarting it
+type Motion struct {
+	pin      machine.Pin
+	relay    Relay
+	hold     time.Duration
+	reportCh chan core.Trigger
+	lux      func() float32
+	below    float32
+	seen     chan struct{}
+}
+
+// NewMotion returns a Motion switching r for hold after each detection on pin, which it configures
+// as an input. r is made retriggerable (see core.Relay's SetRetrigger). Reports go to reportCh.
+func NewMotion(pin machine.Pin, r Relay, hold time.Duration, reportCh chan core.Trigger) *Motion {
+	pin.Configure(machine.PinConfig{Mode: machine.PinInputPulldown})
+	r.SetRetrigger(true)
+	return &Motion{
+		pin:      pin,
+		relay:    r,
+		hold:     hold,
+		reportCh: reportCh,
+		seen:     make(chan struct{}, 1),
+	}
+}
+
+// SetLuxGate only lets movement switch the light on while lux reads below the given level, so it
+// stays off in daylight. Once the light is on, movement keeps it on whatever the reading, as the
+// light itself would otherwise open the gate.
+func (m *Motion) SetLuxGate(lux func() float32, below float32) {
+	m.lux = lux
+	m.below = below
+}
+
+// Run watches the sensor for the life of the program, by pin interrupt where the chip supports it
+// and by polling otherwise
+func (m *Motion) Run() {
+	err := m.pin.SetInterrupt(machine.PinToggle, func(machine.Pin) {
+		select {
+		case m.seen <- struct{}{}:
+		default: // a detection is already pending
+		}
+	})
+	if err != nil {
+		println("relay.Motion polling " + m.relay.Name() + "'s sensor: " + err.Error())
+		go m.poll()
+	}
+	// a sensor held high raises no edges, so its movement is re-asserted well within the hold time
+	every := m.hold / 2
+	if every < motionPoll {
+		every = motionPoll
+	}
+	rearm := time.NewTicker(every)
+	defer rearm.Stop()
+	for {
+		select {
+		case <-m.seen:
+			m.detected()
+		case <-rearm.C:
+			if m.pin.Get() {
+				m.detected()
+			}
+		}
+	}
+}
+
+// poll stands in for the pin interrupt, signalling each edge
+func (m *Motion) poll() {
+	was := m.pin.Get()
+	for {
+		time.Sleep(motionPoll)
+		is := m.pin.Get()
+		if is != was {
+			m.seen <- struct{}{}
+		}
+		was = is
+	}
+}
+
+// detected switches the light on, or restarts its hold time
+func (m *Motion) detected() {
+	if m.lux != nil && !m.relay.Get() && m.lux() >= m.below {
+		return
+	}
+	m.relay.Execute(core.Trigger{
+		Target:   m.relay.Name(),
+		Action:   core.ActionOn,
+		Duration: m.hold,
+		ReportCh: m.reportCh,
+		Source:   core.SourceSensor,
+	})
+}
@@ -0,0 +1,34 @@
+package relay
+
+import (
+	"machine"
+	"strconv"
+
+	"github.com/eyelight/relay/core"
+)
+
+// pinOutput drives a Relay from a GPIO pin
+type pinOutput struct {
+	pin machine.Pin
+}
+
+// PinOutput returns a core.Output driving p
+func PinOutput(p machine.Pin) core.Output {
+	return pinOutput{pin: p}
+}
+
+func (o pinOutput) Configure() {
+	o.pin.Configure(machine.PinConfig{Mode: machine.PinOutput})
+}
+
+func (o pinOutput) Set(level bool) {
+	o.pin.Set(level)
+}
+
+func (o pinOutput) Get() bool {
+	return o.pin.Get()
+}
+
+func (o pinOutput) String() string {
+	return "GPIO " + strconv.Itoa(int(o.pin))
+}
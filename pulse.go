@@ -0,0 +1,14 @@
+//go:build !rp2040
+
+package relay
+
+import (
+	"time"
+
+	"github.com/eyelight/relay/core"
+)
+
+// Pulse is not available on this target, so short runs are timed in software
+func (o pinOutput) Pulse(level bool, d time.Duration) error {
+	return core.ErrNoPulse
+}
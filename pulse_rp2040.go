@@ -0,0 +1,61 @@
+//go:build rp2040
+
+package relay
+
+import (
+	"device/rp"
+	"machine"
+	"runtime"
+	"runtime/interrupt"
+	"runtime/volatile"
+	"sync"
+	"time"
+)
+
+// pulseAlarm is the RP2040 timer alarm that ends pulses; TinyGo's runtime keeps alarm 0 for sleeping
+const pulseAlarm = 3
+
+// pulse is the pulse in flight, which the alarm's interrupt ends by setting pin to end
+var pulse struct {
+	mu   sync.Mutex // one pulse at a time on the alarm
+	once sync.Once  // installs the alarm's interrupt handler
+	pin  machine.Pin
+	end  bool
+	done volatile.Register8
+}
+
+// Pulse sets the pin to level for d, then to the opposite level. The pulse is ended by an
+// interrupt from one of the RP2040's timer alarms, so it is accurate to a few microseconds
+// whatever the scheduler is doing, and other interrupts are serviced meanwhile.
+func (o pinOutput) Pulse(level bool, d time.Duration) error {
+	pulse.mu.Lock()
+	defer pulse.mu.Unlock()
+	pulse.once.Do(func() {
+		interrupt.New(rp.IRQ_TIMER_IRQ_3, endPulse).Enable()
+		rp.TIMER.INTE.SetBits(1 << pulseAlarm)
+	})
+	pulse.pin, pulse.end = o.pin, !level
+	pulse.done.Set(0)
+	o.pin.Set(level)
+	at := rp.TIMER.TIMERAWL.Get() + uint32(d/time.Microsecond)
+	rp.TIMER.ALARM3.Set(at)
+	if int32(rp.TIMER.TIMERAWL.Get()-at) >= 0 && rp.TIMER.ARMED.Get()&(1<<pulseAlarm) != 0 {
+		// the alarm only fires on an exact match, and that has already gone by: disarm it and
+		// end the pulse through its interrupt now
+		rp.TIMER.ARMED.Set(1 << pulseAlarm)
+		rp.TIMER.INTF.SetBits(1 << pulseAlarm)
+	}
+	time.Sleep(d)
+	for pulse.done.Get() == 0 {
+		runtime.Gosched()
+	}
+	return nil
+}
+
+// endPulse handles the pulse alarm's interrupt, ending the pulse in flight
+func endPulse(interrupt.Interrupt) {
+	rp.TIMER.INTF.ClearBits(1 << pulseAlarm)
+	rp.TIMER.INTR.Set(1 << pulseAlarm)
+	pulse.pin.Set(pulse.end)
+	pulse.done.Set(1)
+}
@@ -0,0 +1,112 @@
+package relaytest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/eyelight/relay/core"
+)
+
+// Clock is a core.Clock that only moves when Advance is called. Handed to core.SetClock, it drives
+// Relays' own timing – run expiry, minimum on-time, fallbacks – so a test can step a Relay through
+// an hour-long run without waiting for it; it also suits code that takes its time from a
+// func() time.Time and schedules with AfterFunc.
+type Clock struct {
+	mu     sync.Mutex // guards now & timers
+	now    time.Time
+	timers []*clockTimer
+}
+
+// clockTimer is a function due at a time on a Clock
+type clockTimer struct {
+	c  *Clock
+	at time.Time
+	f  func()
+}
+
+// NewClock returns a Clock reading start
+func NewClock(start time.Time) *Clock {
+	return &Clock{now: start}
+}
+
+// Now returns the Clock's time
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// AfterFunc arranges for f to be called once the Clock has been advanced by d. Unlike
+// time.AfterFunc, f is called in the goroutine calling Advance.
+func (c *Clock) AfterFunc(d time.Duration, f func()) core.Timer {
+	t := &clockTimer{c: c, f: f}
+	c.mu.Lock()
+	t.at = c.now.Add(d)
+	c.timers = append(c.timers, t)
+	c.mu.Unlock()
+	return t
+}
+
+// Advance moves the Clock on by d, calling the functions falling due in order of their due times,
+// each with the Clock reading its due time; functions they arrange that fall due within d are
+// called too
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	end := c.now.Add(d)
+	for {
+		next := -1
+		for i, t := range c.timers {
+			if !t.at.After(end) && (next < 0 || t.at.Before(c.timers[next].at)) {
+				next = i
+			}
+		}
+		if next < 0 {
+			break
+		}
+		t := c.timers[next]
+		c.timers = append(c.timers[:next], c.timers[next+1:]...)
+		if t.at.After(c.now) {
+			c.now = t.at
+		}
+		c.mu.Unlock()
+		t.f()
+		c.mu.Lock()
+	}
+	c.now = end
+	c.mu.Unlock()
+}
+
+// Pending returns how many functions are waiting for the Clock to be advanced
+func (c *Clock) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+// Stop keeps the function from being called, returning false if it already has been or was stopped
+func (t *clockTimer) Stop() bool {
+	t.c.mu.Lock()
+	defer t.c.mu.Unlock()
+	return t.c.remove(t)
+}
+
+// Reset makes the function due d from the Clock's current time, returning whether it was pending
+func (t *clockTimer) Reset(d time.Duration) bool {
+	t.c.mu.Lock()
+	defer t.c.mu.Unlock()
+	pending := t.c.remove(t)
+	t.at = t.c.now.Add(d)
+	t.c.timers = append(t.c.timers, t)
+	return pending
+}
+
+// remove takes t off the Clock, returning whether it was there; it must be called with c.mu held
+func (c *Clock) remove(t *clockTimer) bool {
+	for i, p := range c.timers {
+		if p == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package relaytest_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eyelight/relay/core"
+	"github.com/eyelight/relay/relaytest"
+)
+
+func TestClockTimers(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := relaytest.NewClock(start)
+	var fired []string
+	var at []time.Duration
+	record := func(name string) func() {
+		return func() {
+			fired = append(fired, name)
+			at = append(at, c.Now().Sub(start))
+		}
+	}
+	c.AfterFunc(3*time.Second, record("c"))
+	c.AfterFunc(time.Second, func() {
+		record("a")()
+		c.AfterFunc(time.Second, record("b")) // due within the same Advance
+	})
+	stopped := c.AfterFunc(2*time.Second, record("stopped"))
+	reset := c.AfterFunc(time.Second, record("reset"))
+	if !stopped.Stop() {
+		t.Error("Stop of a pending timer returned false")
+	}
+	reset.Reset(10 * time.Second)
+
+	c.Advance(500 * time.Millisecond)
+	if len(fired) != 0 {
+		t.Fatalf("fired %v before they were due", fired)
+	}
+	c.Advance(4 * time.Second)
+	want := []string{"a", "b", "c"}
+	wantAt := []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}
+	if len(fired) != len(want) {
+		t.Fatalf("fired %v, want %v", fired, want)
+	}
+	for i := range want {
+		if fired[i] != want[i] || at[i] != wantAt[i] {
+			t.Errorf("call %d: %s at %v, want %s at %v", i, fired[i], at[i], want[i], wantAt[i])
+		}
+	}
+	if got := c.Now().Sub(start); got != 4500*time.Millisecond {
+		t.Errorf("Now is %v after start, want 4.5s", got)
+	}
+	if c.Pending() != 1 {
+		t.Errorf("%d pending, want the reset one", c.Pending())
+	}
+	c.Advance(10 * time.Second)
+	if fired[len(fired)-1] != "reset" {
+		t.Errorf("reset timer didn't fire: %v", fired)
+	}
+	if stopped.Stop() {
+		t.Error("Stop of a stopped timer returned true")
+	}
+}
+
+func TestClockDrivesRelay(t *testing.T) {
+	c := relaytest.NewClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	core.SetClock(c)
+	t.Cleanup(func() { core.SetClock(nil) })
+	out := relaytest.NewOutput()
+	r := core.New(out, "heater")
+	r.Configure()
+	rec := relaytest.NewRecorder()
+	r.Execute(core.Trigger{Target: "heater", Action: core.ActionOn, Duration: time.Hour, ReportCh: rec.C(), Source: core.SourceInternal})
+	rec.Expect(t, "On for 1h0m0s", time.Second)
+	waitPending(t, c)
+
+	c.Advance(59 * time.Minute)
+	if left, ok := r.Remaining(); !ok || left != time.Minute {
+		t.Fatalf("Remaining = %v, %v after 59m, want 1m0s, true", left, ok)
+	}
+	if !out.Get() {
+		t.Fatal("off before the hour was up")
+	}
+	c.Advance(time.Minute)
+	rec.Expect(t, "Off after 1h0m0s", time.Second)
+	if out.Get() {
+		t.Error("still on after the hour was up")
+	}
+}
+
+// waitPending waits for a timer to be set on c
+func waitPending(t *testing.T, c *relaytest.Clock) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for c.Pending() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("no timer set on the Clock")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,84 @@
+// Package relaytest provides fakes for testing code built on package core: an Output that records
+// every level it is set to, a Recorder collecting reports with assertion helpers, and a Clock that
+// only moves when told to.
+package relaytest
+
+import (
+	"sync"
+	"time"
+)
+
+// Level is one level set on an Output, and when
+type Level struct {
+	Level bool
+	Time  time.Time
+}
+
+// Output is a core.Output that records every level it is set to
+type Output struct {
+	mu         sync.Mutex // guards all fields
+	configured bool
+	level      bool
+	levels     []Level
+}
+
+// NewOutput returns an Output at the low level
+func NewOutput() *Output {
+	return &Output{}
+}
+
+// Configure marks the Output configured
+func (o *Output) Configure() {
+	o.mu.Lock()
+	o.configured = true
+	o.mu.Unlock()
+}
+
+// Set records level
+func (o *Output) Set(level bool) {
+	o.mu.Lock()
+	o.level = level
+	o.levels = append(o.levels, Level{Level: level, Time: time.Now()})
+	o.mu.Unlock()
+}
+
+// Get returns the last level set
+func (o *Output) Get() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.level
+}
+
+// Configured reports whether Configure has been called
+func (o *Output) Configured() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.configured
+}
+
+// Levels returns every level set so far, oldest first
+func (o *Output) Levels() []Level {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]Level(nil), o.levels...)
+}
+
+// Edges returns the number of times the level actually changed
+func (o *Output) Edges() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	n := 0
+	prev := false
+	for _, l := range o.levels {
+		if l.Level != prev {
+			n++
+		}
+		prev = l.Level
+	}
+	return n
+}
+
+// String describes the Output for core.RelayConfig
+func (o *Output) String() string {
+	return "relaytest.Output"
+}
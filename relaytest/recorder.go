@@ -0,0 +1,98 @@
+package relaytest
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eyelight/relay/core"
+)
+
+// Recorder collects the reports sent to its channel
+type Recorder struct {
+	ch      chan core.Trigger
+	mu      sync.Mutex // guards reports
+	reports []core.Trigger
+	arrived chan struct{} // signalled on each report
+}
+
+// NewRecorder returns a Recorder collecting from its channel, C
+func NewRecorder() *Recorder {
+	rec := &Recorder{
+		ch:      make(chan core.Trigger, 16),
+		arrived: make(chan struct{}, 1),
+	}
+	go func() {
+		for t := range rec.ch {
+			rec.mu.Lock()
+			rec.reports = append(rec.reports, t)
+			rec.mu.Unlock()
+			select {
+			case rec.arrived <- struct{}{}:
+			default:
+			}
+		}
+	}()
+	return rec
+}
+
+// C returns the channel to hand out as a ReportCh
+func (rec *Recorder) C() chan core.Trigger {
+	return rec.ch
+}
+
+// Reports returns the reports collected so far, oldest first
+func (rec *Recorder) Reports() []core.Trigger {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return append([]core.Trigger(nil), rec.reports...)
+}
+
+// Wait waits up to timeout for at least n reports, failing tb if they don't arrive, and returns them
+func (rec *Recorder) Wait(tb testing.TB, n int, timeout time.Duration) []core.Trigger {
+	tb.Helper()
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	for {
+		if reports := rec.Reports(); len(reports) >= n {
+			return reports
+		}
+		select {
+		case <-rec.arrived:
+		case <-deadline.C:
+			tb.Fatalf("relaytest: %d reports after %v, want %d", len(rec.Reports()), timeout, n)
+			return nil
+		}
+	}
+}
+
+// Expect waits up to timeout for a report whose Message contains substr, failing tb if none arrives
+func (rec *Recorder) Expect(tb testing.TB, substr string, timeout time.Duration) core.Trigger {
+	tb.Helper()
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	for {
+		for _, t := range rec.Reports() {
+			if strings.Contains(t.Message, substr) {
+				return t
+			}
+		}
+		select {
+		case <-rec.arrived:
+		case <-deadline.C:
+			tb.Fatalf("relaytest: no report containing %q after %v", substr, timeout)
+			return core.Trigger{}
+		}
+	}
+}
+
+// ExpectNoErrors fails tb for every report collected so far with Error set
+func (rec *Recorder) ExpectNoErrors(tb testing.TB) {
+	tb.Helper()
+	for _, t := range rec.Reports() {
+		if t.Error {
+			tb.Errorf("relaytest: error report: %s", t.Message)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package relay
+
+import (
+	"machine"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/eyelight/relay/core"
+)
+
+// RFProtocol describes how an RF433 remote socket expects its codes: the base pulse length and,
+// as multiples of it, the high & low lengths of the sync pulse and of each 0 and 1 bit.
+// The presets match the rc-switch library's protocols 1 and 2.
+type RFProtocol struct {
+	PulseLength time.Duration
+	Sync        [2]uint8
+	Zero        [2]uint8
+	One         [2]uint8
+	Repeats     uint8 // how many times each code is sent
+}
+
+var (
+	RFProtocol1 = RFProtocol{PulseLength: 350 * time.Microsecond, Sync: [2]uint8{1, 31}, Zero: [2]uint8{1, 3}, One: [2]uint8{3, 1}, Repeats: 10}
+	RFProtocol2 = RFProtocol{PulseLength: 650 * time.Microsecond, Sync: [2]uint8{1, 10}, Zero: [2]uint8{1, 2}, One: [2]uint8{2, 1}, Repeats: 10}
+)
+
+// RFCode is the pair of codes switching one remote socket, as captured from its remote control
+type RFCode struct {
+	On   uint32
+	Off  uint32
+	Bits uint8 // code length, usually 24
+}
+
+// RFTransmitter is a 433MHz transmitter module on a GPIO pin, shared by any number of remote sockets
+type RFTransmitter struct {
+	pin machine.Pin
+	mu  sync.Mutex // one code on the air at a time
+}
+
+// NewRFTransmitter returns an RFTransmitter on pin tx, which it configures
+func NewRFTransmitter(tx machine.Pin) *RFTransmitter {
+	tx.Configure(machine.PinConfig{Mode: machine.PinOutput})
+	tx.Low()
+	return &RFTransmitter{pin: tx}
+}
+
+// rfOutput switches one remote socket through a transmitter
+type rfOutput struct {
+	tx        *RFTransmitter
+	protocol  RFProtocol
+	code      RFCode
+	resend    time.Duration
+	resending bool
+	mu        sync.Mutex // guards level
+	level     bool
+}
+
+// Output returns a core.Output switching the socket answering to code. Remote sockets give no
+// feedback, so the current code is re-sent every resend (0 disables this) to recover from a
+// missed transmission, and Get returns the last level sent.
+func (tx *RFTransmitter) Output(p RFProtocol, code RFCode, resend time.Duration) core.Output {
+	return &rfOutput{
+		tx:       tx,
+		protocol: p,
+		code:     code,
+		resend:   resend,
+	}
+}
+
+// NewRF433 returns a Relay switching the remote socket answering to code through tx
+func NewRF433(tx *RFTransmitter, p RFProtocol, code RFCode, resend time.Duration, name string) Relay {
+	return core.New(tx.Output(p, code, resend), name)
+}
+
+func (o *rfOutput) Configure() {
+	if o.resend > 0 && !o.resending {
+		o.resending = true
+		go o.resendLoop()
+	}
+}
+
+func (o *rfOutput) Set(level bool) {
+	o.mu.Lock()
+	o.level = level
+	o.mu.Unlock()
+	o.send(level)
+}
+
+func (o *rfOutput) Get() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.level
+}
+
+// Slow reports that a code takes a burst of ~0.45s to send, so an "Off" is left to the worker
+func (o *rfOutput) Slow() bool {
+	return true
+}
+
+func (o *rfOutput) String() string {
+	return "RF433 code " + strconv.FormatUint(uint64(o.code.On), 10) + "/" + strconv.FormatUint(uint64(o.code.Off), 10)
+}
+
+// resendLoop re-sends the current code for the life of the Output
+func (o *rfOutput) resendLoop() {
+	for {
+		time.Sleep(o.resend)
+		o.send(o.Get())
+	}
+}
+
+// send transmits the code for level, most significant bit first, followed by the sync pulse
+func (o *rfOutput) send(level bool) {
+	code := o.code.Off
+	if level {
+		code = o.code.On
+	}
+	p := o.protocol
+	o.tx.mu.Lock()
+	defer o.tx.mu.Unlock()
+	for rep := uint8(0); rep < p.Repeats; rep++ {
+		for i := int(o.code.Bits) - 1; i >= 0; i-- {
+			if code&(1<<uint(i)) != 0 {
+				o.tx.pulse(p.PulseLength, p.One)
+			} else {
+				o.tx.pulse(p.PulseLength, p.Zero)
+			}
+		}
+		o.tx.pulse(p.PulseLength, p.Sync)
+	}
+	o.tx.pin.Low()
+}
+
+// pulse holds the pin high then low for the given multiples of the pulse length
+func (tx *RFTransmitter) pulse(length time.Duration, hl [2]uint8) {
+	tx.pin.High()
+	time.Sleep(length * time.Duration(hl[0]))
+	tx.pin.Low()
+	time.Sleep(length * time.Duration(hl[1]))
+}
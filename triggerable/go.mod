@@ -0,0 +1,7 @@
+module github.com/eyelight/relay/triggerable
+
+go 1.18
+
+require github.com/eyelight/relay v0.0.0
+
+replace github.com/eyelight/relay => ../
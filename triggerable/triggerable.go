@@ -0,0 +1,96 @@
+// Package triggerable adapts the Relays and Schedulers of package core to github.com/eyelight/trigger,
+// so they can be added to a trigger Dispatcher while package core itself stays free of the dependency.
+package triggerable
+
+import (
+	"sync"
+
+	"github.com/eyelight/relay/core"
+	"github.com/eyelight/trigger"
+)
+
+// Executor is anything in package core that takes Triggers, such as a Relay, a Scheduler or a core.Chain
+type Executor = core.Executor
+
+// adapter presents an Executor as a trigger.Triggerable
+type adapter struct {
+	x Executor
+}
+
+// Wrap returns x as a trigger.Triggerable, ready for a Dispatcher's AddToDispatch
+func Wrap(x Executor) trigger.Triggerable {
+	return adapter{x: x}
+}
+
+// Name returns the wrapped Executor's name
+func (a adapter) Name() string {
+	return a.x.Name()
+}
+
+// Execute hands t to the wrapped Executor; its reports arrive on t.ReportCh as usual
+func (a adapter) Execute(t trigger.Trigger) {
+	a.x.Execute(From(t))
+}
+
+// From converts t to a core.Trigger from SourceRemote whose reports are forwarded to t.ReportCh
+func From(t trigger.Trigger) core.Trigger {
+	return core.Trigger{
+		Target:   t.Target,
+		Action:   core.Action(t.Action),
+		Duration: t.Duration,
+		Message:  t.Message,
+		Error:    t.Error,
+		ReportCh: Reports(t.ReportCh),
+		Source:   core.SourceRemote,
+	}
+}
+
+// To converts a core.Trigger, typically a report, to a trigger.Trigger without a ReportCh
+func To(t core.Trigger) trigger.Trigger {
+	return trigger.Trigger{
+		Target:   t.Target,
+		Action:   string(t.Action),
+		Duration: t.Duration,
+		Message:  t.Message,
+		Error:    t.Error,
+	}
+}
+
+var (
+	bridgesMu sync.Mutex // guards bridges
+	bridges   = map[chan trigger.Trigger]chan core.Trigger{}
+)
+
+// Reports returns a channel for package core's reports whose contents are forwarded to ch, e.g. for
+// a Scheduler's or Humidistat's report channel. Each ch gets one forwarding goroutine, however often
+// it is asked for, until Release. A nil ch gives a nil channel, so the Relay's report fallback applies.
+func Reports(ch chan trigger.Trigger) chan core.Trigger {
+	if ch == nil {
+		return nil
+	}
+	bridgesMu.Lock()
+	defer bridgesMu.Unlock()
+	if b, ok := bridges[ch]; ok {
+		return b
+	}
+	b := make(chan core.Trigger)
+	bridges[ch] = b
+	go func() {
+		for t := range b {
+			ch <- To(t)
+		}
+	}()
+	return b
+}
+
+// Release ends the forwarding Reports set up for ch, once nothing will report to it again – e.g. when
+// a transport that makes a ReportCh per message is done with one – so its goroutine doesn't outlive it
+func Release(ch chan trigger.Trigger) {
+	bridgesMu.Lock()
+	b, ok := bridges[ch]
+	delete(bridges, ch)
+	bridgesMu.Unlock()
+	if ok {
+		close(b)
+	}
+}